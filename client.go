@@ -0,0 +1,37 @@
+package net
+
+import (
+	"context"
+	"net/netip"
+)
+
+// Client describes the original client of the request, as resolved from headers set by trusted proxies.
+type Client struct {
+	// IP is the address of the client.
+	IP netip.Addr
+	// Scheme is the scheme (http or https) the client used to connect to the first proxy.
+	Scheme string
+	// Host is the host requested by the client.
+	Host string
+}
+
+// WithClient saves the client information into the context.
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, contextKey.client, client)
+}
+
+// ClientFromCtx extracts the client information from the context.
+func ClientFromCtx(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(contextKey.client).(Client)
+	return client, ok
+}
+
+// ClientIPFromCtx extracts the client IP address from the context.
+// It returns an empty string if the address is not present.
+func ClientIPFromCtx(ctx context.Context) string {
+	client, ok := ClientFromCtx(ctx)
+	if !ok || !client.IP.IsValid() {
+		return ""
+	}
+	return client.IP.String()
+}
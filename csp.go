@@ -0,0 +1,18 @@
+package net
+
+import "context"
+
+// WithCSPNonce saves the Content-Security-Policy nonce generated for the request into the context.
+func WithCSPNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, contextKey.cspNonce, nonce)
+}
+
+// CSPNonceFromCtx extracts the Content-Security-Policy nonce generated for the request from the context.
+// It is meant to be used in templates, e.g. <script nonce="{{ .Nonce }}">.
+func CSPNonceFromCtx(ctx context.Context) string {
+	nonce, ok := ctx.Value(contextKey.cspNonce).(string)
+	if !ok {
+		return ""
+	}
+	return nonce
+}
@@ -0,0 +1,128 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+const (
+	maxStackDepth = 32
+	// stackSkip skips runtime.Callers and New in the captured stack.
+	stackSkip = 2
+)
+
+// Error is an application error carrying everything needed to report it over any transport:
+// the kind, a stable machine code and a message safe to be shown to clients.
+// Details and the cause are internal, they are meant for logs and never returned to clients.
+//
+// Domain code returns Error without depending on HTTP or GraphQL, transports map it by the kind,
+// see go.strv.io/net/http.WithAppError and go.strv.io/net/graphql/errorpresenter.
+type Error struct {
+	Kind Kind
+	// Code is the stable machine code, e.g. ERR_USER_NOT_FOUND. Kind.Code is used if empty.
+	Code string
+	// Message is the public message, safe to be shown to clients.
+	Message string
+	// Details are internal details of the error, e.g. IDs of the involved entities.
+	Details map[string]any
+
+	cause error
+	stack []uintptr
+}
+
+type Option func(*Error)
+
+// WithCause sets the underlying error, it is available by errors.Unwrap.
+func WithCause(err error) Option {
+	return func(e *Error) {
+		e.cause = err
+	}
+}
+
+// WithDetail adds an internal detail of the error.
+func WithDetail(key string, value any) Option {
+	return func(e *Error) {
+		if e.Details == nil {
+			e.Details = make(map[string]any)
+		}
+		e.Details[key] = value
+	}
+}
+
+// WithStack captures the stack of the caller of New, see Error.Stack.
+func WithStack() Option {
+	return func(e *Error) {
+		e.stack = []uintptr{}
+	}
+}
+
+// New creates an application error of the kind. If the code is empty, the default code of the kind is used.
+func New(kind Kind, code, message string, opts ...Option) *Error {
+	if code == "" {
+		code = kind.Code()
+	}
+	e := &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.stack != nil {
+		pcs := make([]uintptr, maxStackDepth)
+		n := runtime.Callers(stackSkip, pcs)
+		e.stack = pcs[:n]
+	}
+	return e
+}
+
+// Error returns the code, the message and the cause of the error.
+func (e *Error) Error() string {
+	msg := e.Code
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	if e.cause != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.cause)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Stack returns the stack captured by WithStack, nil if the stack was not captured.
+func (e *Error) Stack() []runtime.Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+	frames := runtime.CallersFrames(e.stack)
+	var stack []runtime.Frame
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame)
+		if !more {
+			return stack
+		}
+	}
+}
+
+// AsError finds the first Error in the chain of err.
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of the first Error in the chain of err, KindUnknown if there is none.
+func KindOf(err error) Kind {
+	if e, ok := AsError(err); ok {
+		return e.Kind
+	}
+	return KindUnknown
+}
@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNoRows = errors.New("no rows")
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       *Error
+		wantCode  string
+		wantError string
+	}{
+		{
+			name:      "success:code",
+			err:       New(KindNotFound, "ERR_USER_NOT_FOUND", "user not found"),
+			wantCode:  "ERR_USER_NOT_FOUND",
+			wantError: "ERR_USER_NOT_FOUND: user not found",
+		},
+		{
+			name:      "success:default-code",
+			err:       New(KindPermissionDenied, "", ""),
+			wantCode:  "ERR_PERMISSION_DENIED",
+			wantError: "ERR_PERMISSION_DENIED",
+		},
+		{
+			name:      "success:cause",
+			err:       New(KindNotFound, "", "user not found", WithCause(errNoRows)),
+			wantCode:  "ERR_NOT_FOUND",
+			wantError: "ERR_NOT_FOUND: user not found: no rows",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantCode, tt.err.Code)
+			assert.EqualError(t, tt.err, tt.wantError)
+		})
+	}
+}
+
+func TestError_Unwrap(t *testing.T) {
+	err := fmt.Errorf("getting user: %w", New(KindNotFound, "", "user not found", WithCause(errNoRows), WithDetail("userId", "1")))
+
+	assert.ErrorIs(t, err, errNoRows)
+	assert.Equal(t, KindNotFound, KindOf(err))
+	assert.Equal(t, KindUnknown, KindOf(errNoRows))
+	appErr, ok := AsError(err)
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"userId": "1"}, appErr.Details)
+}
+
+func TestError_Stack(t *testing.T) {
+	assert.Nil(t, New(KindInternal, "", "").Stack())
+
+	stack := New(KindInternal, "", "", WithStack()).Stack()
+	require.NotEmpty(t, stack)
+	assert.True(t, strings.HasSuffix(stack[0].Function, "TestError_Stack"), stack[0].Function)
+}
+
+func TestKind_String(t *testing.T) {
+	assert.Equal(t, "NOT_FOUND", KindNotFound.String())
+	assert.Equal(t, "UNKNOWN", Kind(-1).String())
+	assert.Equal(t, "ERR_INVALID_ARGUMENT", KindInvalidArgument.Code())
+}
@@ -0,0 +1,60 @@
+package errors
+
+// Kind classifies an application error independently of the transport, e.g. KindNotFound
+// is mapped to 404 Not Found over HTTP and to "NOT_FOUND" extension code over GraphQL.
+type Kind int
+
+const (
+	// KindUnknown is the kind of errors without a better classification, it is treated as an internal error.
+	KindUnknown Kind = iota
+	// KindInternal means a bug or an unexpected state of the application.
+	KindInternal
+	// KindInvalidArgument means the input of the client is invalid.
+	KindInvalidArgument
+	// KindNotFound means the requested resource does not exist.
+	KindNotFound
+	// KindAlreadyExists means the resource the client tried to create already exists.
+	KindAlreadyExists
+	// KindConflict means the request conflicts with the current state of the resource, e.g. a concurrent update.
+	KindConflict
+	// KindUnauthenticated means the client is not authenticated.
+	KindUnauthenticated
+	// KindPermissionDenied means the authenticated client is not allowed to perform the operation.
+	KindPermissionDenied
+	// KindTooManyRequests means a rate limit or a quota of the client is exhausted.
+	KindTooManyRequests
+	// KindUnimplemented means the operation is not implemented.
+	KindUnimplemented
+	// KindUnavailable means the service or its dependency is temporarily unavailable, the client can retry.
+	KindUnavailable
+	// KindDeadlineExceeded means the operation did not finish in time.
+	KindDeadlineExceeded
+)
+
+var kindNames = map[Kind]string{
+	KindUnknown:          "UNKNOWN",
+	KindInternal:         "INTERNAL",
+	KindInvalidArgument:  "INVALID_ARGUMENT",
+	KindNotFound:         "NOT_FOUND",
+	KindAlreadyExists:    "ALREADY_EXISTS",
+	KindConflict:         "CONFLICT",
+	KindUnauthenticated:  "UNAUTHENTICATED",
+	KindPermissionDenied: "PERMISSION_DENIED",
+	KindTooManyRequests:  "TOO_MANY_REQUESTS",
+	KindUnimplemented:    "UNIMPLEMENTED",
+	KindUnavailable:      "UNAVAILABLE",
+	KindDeadlineExceeded: "DEADLINE_EXCEEDED",
+}
+
+// String returns the name of the kind, e.g. NOT_FOUND.
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return kindNames[KindUnknown]
+}
+
+// Code returns the default machine code of errors of the kind, e.g. ERR_NOT_FOUND.
+func (k Kind) Code() string {
+	return "ERR_" + k.String()
+}
@@ -0,0 +1,43 @@
+package errorpresenter
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	neterrors "go.strv.io/net/errors"
+)
+
+const (
+	// CodeExtension is the extension of GraphQL errors containing the code of the application error.
+	CodeExtension = "code"
+	// KindExtension is the extension of GraphQL errors containing the kind of the application error, e.g. NOT_FOUND.
+	KindExtension = "kind"
+)
+
+// Present is a graphql.ErrorPresenterFunc mapping application errors (see neterrors.Error) to GraphQL errors.
+// The message of the GraphQL error is the public message of the application error, the code and the kind
+// are added as extensions. Other errors are presented by graphql.DefaultErrorPresenter.
+//
+// Usage:
+//
+//	gqlServer := handler.New(schema)
+//	gqlServer.SetErrorPresenter(errorpresenter.Present)
+func Present(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	appErr, ok := neterrors.AsError(err)
+	if !ok || gqlErr == nil {
+		return gqlErr
+	}
+	gqlErr.Message = appErr.Message
+	if gqlErr.Message == "" {
+		gqlErr.Message = appErr.Code
+	}
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = make(map[string]any)
+	}
+	gqlErr.Extensions[CodeExtension] = appErr.Code
+	gqlErr.Extensions[KindExtension] = appErr.Kind.String()
+	return gqlErr
+}
@@ -0,0 +1,53 @@
+package errorpresenter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	neterrors "go.strv.io/net/errors"
+)
+
+func TestPresent(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantMessage    string
+		wantExtensions map[string]any
+	}{
+		{
+			name:        "success:app-error",
+			err:         fmt.Errorf("resolving user: %w", neterrors.New(neterrors.KindNotFound, "ERR_USER_NOT_FOUND", "user not found")),
+			wantMessage: "user not found",
+			wantExtensions: map[string]any{
+				"code": "ERR_USER_NOT_FOUND",
+				"kind": "NOT_FOUND",
+			},
+		},
+		{
+			name:        "success:without-message",
+			err:         neterrors.New(neterrors.KindUnauthenticated, "", "", neterrors.WithCause(errors.New("token expired"))),
+			wantMessage: "ERR_UNAUTHENTICATED",
+			wantExtensions: map[string]any{
+				"code": "ERR_UNAUTHENTICATED",
+				"kind": "UNAUTHENTICATED",
+			},
+		},
+		{
+			name:        "success:other-error",
+			err:         errors.New("boom"),
+			wantMessage: "boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gqlErr := Present(context.Background(), tt.err)
+
+			assert.Equal(t, tt.wantMessage, gqlErr.Message)
+			assert.Equal(t, tt.wantExtensions, gqlErr.Extensions)
+		})
+	}
+}
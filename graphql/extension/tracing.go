@@ -0,0 +1,92 @@
+package extension
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingScopeName is the instrumentation scope name of the tracer created by Tracing.
+const TracingScopeName = "go.strv.io/net/graphql/extension"
+
+type Tracing struct {
+	tracer trace.Tracer
+}
+
+type TracingOptions struct {
+	tracerProvider trace.TracerProvider
+}
+
+type TracingOption func(*TracingOptions)
+
+// WithTracerProvider sets the provider of the tracer. Default is the global provider (otel.GetTracerProvider).
+func WithTracerProvider(tp trace.TracerProvider) TracingOption {
+	return func(o *TracingOptions) {
+		o.tracerProvider = tp
+	}
+}
+
+// OperationTracing creates an extension starting an OpenTelemetry span for each GraphQL operation response.
+// The span is named by the operation type and name (e.g. "query GetUser"), and it is a child of the span
+// in the context, e.g. the server span of the HTTP request. If the response contains errors, the span status
+// is set to error.
+func OperationTracing(opts ...TracingOption) *Tracing {
+	o := TracingOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.tracerProvider == nil {
+		o.tracerProvider = otel.GetTracerProvider()
+	}
+	return &Tracing{
+		tracer: o.tracerProvider.Tracer(TracingScopeName),
+	}
+}
+
+var _ interface {
+	graphql.ResponseInterceptor
+	graphql.HandlerExtension
+} = &Tracing{}
+
+func (t *Tracing) ExtensionName() string {
+	return "OperationTracing"
+}
+
+func (t *Tracing) Validate(_ graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (t *Tracing) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	opCtx := graphql.GetOperationContext(ctx)
+
+	name := "GraphQL Operation"
+	var opts []trace.SpanStartOption
+	if opCtx.Operation != nil {
+		opType := string(opCtx.Operation.Operation)
+		name = strings.TrimSpace(opType + " " + opCtx.Operation.Name)
+		opts = append(opts, trace.WithAttributes(semconv.GraphQLOperationTypeKey.String(opType)))
+	}
+	if opCtx.OperationName != "" {
+		opts = append(opts, trace.WithAttributes(semconv.GraphQLOperationName(opCtx.OperationName)))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, opts...)
+	defer span.End()
+
+	resp := next(ctx)
+	if resp != nil && len(resp.Errors) > 0 {
+		for _, err := range resp.Errors {
+			span.RecordError(err)
+		}
+		span.SetStatus(codes.Error, resp.Errors.Error())
+	}
+	return resp
+}
@@ -0,0 +1,45 @@
+package extension
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOperationTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	exec := executor.New(executableSchema{})
+	exec.Use(OperationTracing(WithTracerProvider(tp)))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	ctx = graphql.StartOperationTrace(ctx)
+	opCtx, err := exec.CreateOperationContext(ctx, &graphql.RawParams{
+		Query:         queries,
+		OperationName: "Allowed",
+	})
+	require.Nil(t, err)
+	handler, ctx := exec.DispatchOperation(ctx, opCtx)
+	handler(ctx)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "query Allowed", span.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+	assert.Equal(t, codes.Unset, span.Status.Code)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("graphql.operation.type", "query"),
+		attribute.String("graphql.operation.name", "Allowed"),
+	}, span.Attributes)
+}
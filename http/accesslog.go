@@ -0,0 +1,259 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.strv.io/net/internal"
+)
+
+const (
+	// clfTimeLayout is the time layout of Common Log Format, e.g. 10/Oct/2000:13:55:36 -0700.
+	clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+	gcpTraceKey  = "logging.googleapis.com/trace"
+	gcpSpanIDKey = "logging.googleapis.com/spanId"
+)
+
+// AccessLogFormat formats data of the processed request as an access log entry.
+// LogAttrs returns attributes logged through slog by LoggingMiddleware with WithAccessLogFormat,
+// Write writes a single line to the writer used by AccessLogMiddleware.
+type AccessLogFormat interface {
+	LogAttrs(rd RequestData) []slog.Attr
+	Write(w io.Writer, rd RequestData) error
+}
+
+// CommonLogFormat is the Apache Common Log Format, e.g.:
+//
+//	192.0.2.1 - alice [10/Oct/2000:13:55:36 -0700] "GET /users?page=2 HTTP/1.1" 200 2326
+//
+// The user is the principal saved by net.WithPrincipal. Through slog, the line is logged as the request attribute.
+type CommonLogFormat struct{}
+
+func (CommonLogFormat) LogAttrs(rd RequestData) []slog.Attr {
+	return []slog.Attr{slog.String("request", commonLogLine(rd))}
+}
+
+func (CommonLogFormat) Write(w io.Writer, rd RequestData) error {
+	_, err := io.WriteString(w, commonLogLine(rd)+"\n")
+	return err
+}
+
+// CombinedLogFormat is the Apache Combined Log Format, which is CommonLogFormat followed by the referer
+// and the user agent, e.g.:
+//
+//	192.0.2.1 - alice [10/Oct/2000:13:55:36 -0700] "GET /users HTTP/1.1" 200 2326 "https://example.com/" "curl/8.0"
+type CombinedLogFormat struct{}
+
+func (CombinedLogFormat) LogAttrs(rd RequestData) []slog.Attr {
+	return []slog.Attr{slog.String("request", combinedLogLine(rd))}
+}
+
+func (CombinedLogFormat) Write(w io.Writer, rd RequestData) error {
+	_, err := io.WriteString(w, combinedLogLine(rd)+"\n")
+	return err
+}
+
+func commonLogLine(rd RequestData) string {
+	bytesOut := "-"
+	if rd.BytesOut > 0 {
+		bytesOut = strconv.FormatInt(rd.BytesOut, 10)
+	}
+	return fmt.Sprintf("%s - %s [%s] %q %d %s",
+		clfValue(rd.remoteIP()),
+		clfValue(rd.Principal),
+		rd.StartTime.Format(clfTimeLayout),
+		rd.Method+" "+requestURI(rd)+" "+rd.Proto,
+		rd.ResponseStatusCode,
+		bytesOut,
+	)
+}
+
+func combinedLogLine(rd RequestData) string {
+	return fmt.Sprintf("%s %q %q", commonLogLine(rd), clfValue(rd.Referer), clfValue(rd.UserAgent))
+}
+
+// clfValue replaces empty values by "-" and spaces by "_" to keep the fields of the line separable.
+func clfValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, " ", "_")
+}
+
+// ECSFormat formats the request using Elastic Common Schema fields (http.*, url.*, client.ip, user_agent.original,
+// event.duration, trace.id, user.id, ...). Write writes one JSON document per line.
+type ECSFormat struct{}
+
+func (ECSFormat) LogAttrs(rd RequestData) []slog.Attr {
+	request := []any{
+		slog.String("method", rd.Method),
+		slog.Group("body", slog.Int64("bytes", rd.BytesIn)),
+	}
+	request = appendNonEmpty(request, "id", rd.RequestID)
+	request = appendNonEmpty(request, "referrer", rd.Referer)
+	httpAttrs := []any{
+		slog.Group("request", request...),
+		slog.Group("response",
+			slog.Int("status_code", rd.ResponseStatusCode),
+			slog.Group("body", slog.Int64("bytes", rd.BytesOut)),
+		),
+	}
+	httpAttrs = appendNonEmpty(httpAttrs, "version", strings.TrimPrefix(rd.Proto, "HTTP/"))
+
+	url := appendNonEmpty([]any{slog.String("path", rd.Path)}, "query", rd.Query)
+	attrs := []slog.Attr{
+		slog.Time("@timestamp", rd.StartTime),
+		slog.Group("http", httpAttrs...),
+		slog.Group("url", url...),
+		slog.Group("event", slog.Int64("duration", rd.Duration.Nanoseconds())),
+	}
+	if ip := rd.remoteIP(); ip != "" {
+		attrs = append(attrs, slog.Group("client", slog.String("ip", ip)))
+	}
+	if rd.UserAgent != "" {
+		attrs = append(attrs, slog.Group("user_agent", slog.String("original", rd.UserAgent)))
+	}
+	if rd.TraceID != "" {
+		attrs = append(attrs,
+			slog.Group("trace", slog.String("id", rd.TraceID)),
+			slog.Group("span", slog.String("id", rd.SpanID)),
+		)
+	}
+	if rd.Principal != "" {
+		attrs = append(attrs, slog.Group("user", slog.String("id", rd.Principal)))
+	}
+	return attrs
+}
+
+func (f ECSFormat) Write(w io.Writer, rd RequestData) error {
+	return writeJSONAttrs(w, f.LogAttrs(rd))
+}
+
+// GCPFormat formats the request as the httpRequest field of Google Cloud structured logging, including
+// the trace correlation fields. Write writes one JSON document per line.
+type GCPFormat struct {
+	// ProjectID is used to build the full trace resource name (projects/PROJECT_ID/traces/TRACE_ID).
+	// If empty, only the trace ID is logged.
+	ProjectID string
+}
+
+func (f GCPFormat) LogAttrs(rd RequestData) []slog.Attr {
+	httpRequest := []any{
+		slog.String("requestMethod", rd.Method),
+		slog.String("requestUrl", requestURI(rd)),
+		slog.String("requestSize", strconv.FormatInt(rd.BytesIn, 10)),
+		slog.Int("status", rd.ResponseStatusCode),
+		slog.String("responseSize", strconv.FormatInt(rd.BytesOut, 10)),
+		slog.String("latency", strconv.FormatFloat(rd.Duration.Seconds(), 'f', -1, 64)+"s"),
+	}
+	httpRequest = appendNonEmpty(httpRequest, "userAgent", rd.UserAgent)
+	httpRequest = appendNonEmpty(httpRequest, "remoteIp", rd.remoteIP())
+	httpRequest = appendNonEmpty(httpRequest, "referer", rd.Referer)
+	httpRequest = appendNonEmpty(httpRequest, "protocol", rd.Proto)
+
+	attrs := []slog.Attr{slog.Group("httpRequest", httpRequest...)}
+	if rd.TraceID != "" {
+		trace := rd.TraceID
+		if f.ProjectID != "" {
+			trace = "projects/" + f.ProjectID + "/traces/" + rd.TraceID
+		}
+		attrs = append(attrs, slog.String(gcpTraceKey, trace), slog.String(gcpSpanIDKey, rd.SpanID))
+	}
+	return attrs
+}
+
+func (f GCPFormat) Write(w io.Writer, rd RequestData) error {
+	return writeJSONAttrs(w, f.LogAttrs(rd))
+}
+
+// AWSFormat formats the request as flat JSON with keys following API Gateway access log variables
+// (requestId, ip, httpMethod, resourcePath, status, responseLatency, ...), which is convenient
+// for CloudWatch Logs Insights queries. The trace ID is logged in the X-Ray format.
+// Write writes one JSON document per line.
+type AWSFormat struct{}
+
+func (AWSFormat) LogAttrs(rd RequestData) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("requestId", rd.RequestID),
+		slog.String("requestTime", rd.StartTime.Format(clfTimeLayout)),
+		slog.Int64("requestTimeEpoch", rd.StartTime.UnixMilli()),
+		slog.String("httpMethod", rd.Method),
+		slog.String("path", rd.Path),
+		slog.String("protocol", rd.Proto),
+		slog.Int("status", rd.ResponseStatusCode),
+		slog.Int64("requestLength", rd.BytesIn),
+		slog.Int64("responseLength", rd.BytesOut),
+		slog.Int64("responseLatency", rd.Duration.Milliseconds()),
+	}
+	for _, a := range []struct{ key, value string }{
+		{"ip", rd.remoteIP()},
+		{"resourcePath", rd.Route},
+		{"userAgent", rd.UserAgent},
+		{"principalId", rd.Principal},
+	} {
+		if a.value != "" {
+			attrs = append(attrs, slog.String(a.key, a.value))
+		}
+	}
+	if len(rd.TraceID) == traceIDLength {
+		attrs = append(attrs, slog.String("xrayTraceId", amazonTraceID(rd.TraceID)))
+	}
+	return attrs
+}
+
+func (f AWSFormat) Write(w io.Writer, rd RequestData) error {
+	return writeJSONAttrs(w, f.LogAttrs(rd))
+}
+
+// AccessLogMiddleware writes an access log line of each request to the writer in the format.
+// Requests are collected the same way as by LoggingMiddleware, so options like WithSkippedPaths, WithSampledPaths
+// and WithLevelFunc (used for sampling) apply. Writes are serialized, so the writer does not have to be safe
+// for concurrent use.
+func AccessLogMiddleware(w io.Writer, f AccessLogFormat, opts ...LoggingMiddlewareOption) func(http.Handler) http.Handler {
+	options := newLoggingMiddlewareOptions(opts)
+	var mu sync.Mutex
+	return requestLogMiddleware(internal.NewNopLogger(), options, func(_ *http.Request, _ *ResponseWriter, rd RequestData, _ slog.Level) {
+		mu.Lock()
+		defer mu.Unlock()
+		// There is nowhere to report the failed write to, the response has already been sent.
+		_ = f.Write(w, rd)
+	})
+}
+
+// requestURI returns the escaped path with the raw query of the request.
+func requestURI(rd RequestData) string {
+	if rd.Query == "" {
+		return rd.Path
+	}
+	return rd.Path + "?" + rd.Query
+}
+
+func appendNonEmpty(attrs []any, key, value string) []any {
+	if value == "" {
+		return attrs
+	}
+	return append(attrs, slog.String(key, value))
+}
+
+// writeJSONAttrs writes the attributes as a single line JSON document without time, level and message keys.
+func writeJSONAttrs(w io.Writer, attrs []slog.Attr) error {
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	record := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+	record.AddAttrs(attrs...)
+	return h.Handle(context.Background(), record)
+}
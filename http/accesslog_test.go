@@ -0,0 +1,222 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+)
+
+func testRequestData() RequestData {
+	return RequestData{
+		Path:               "/users/1",
+		Method:             http.MethodGet,
+		StartTime:          time.Date(2000, time.October, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60)),
+		Duration:           1500 * time.Millisecond,
+		ResponseStatusCode: http.StatusOK,
+		RequestID:          "b6a7fd1e-0c3e-4e3b-9d1a-7a8b4c1f5e2d",
+		RemoteAddr:         "192.0.2.1:1234",
+		TraceID:            "5759e988bd862e3fe1be46a994272793",
+		SpanID:             "53995c3f42cd8ad8",
+		Principal:          "alice",
+		UserAgent:          "curl/8.0",
+		Referer:            "https://example.com/",
+		Query:              "page=2",
+		Proto:              "HTTP/1.1",
+		Route:              "/users/{id}",
+		BytesIn:            7,
+		BytesOut:           2326,
+	}
+}
+
+func TestAccessLogFormat_Write(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   AccessLogFormat
+		rd       func(rd *RequestData)
+		wantLine string
+		wantJSON map[string]any
+	}{
+		{
+			name:     "success:common",
+			format:   CommonLogFormat{},
+			wantLine: `192.0.2.1 - alice [10/Oct/2000:13:55:36 -0700] "GET /users/1?page=2 HTTP/1.1" 200 2326`,
+		},
+		{
+			name:   "success:common-empty-values",
+			format: CommonLogFormat{},
+			rd: func(rd *RequestData) {
+				rd.ClientIP = "203.0.113.7"
+				rd.Principal = ""
+				rd.Query = ""
+				rd.BytesOut = 0
+			},
+			wantLine: `203.0.113.7 - - [10/Oct/2000:13:55:36 -0700] "GET /users/1 HTTP/1.1" 200 -`,
+		},
+		{
+			name:     "success:combined",
+			format:   CombinedLogFormat{},
+			wantLine: `192.0.2.1 - alice [10/Oct/2000:13:55:36 -0700] "GET /users/1?page=2 HTTP/1.1" 200 2326 "https://example.com/" "curl/8.0"`,
+		},
+		{
+			name:   "success:ecs",
+			format: ECSFormat{},
+			wantJSON: map[string]any{
+				"@timestamp": "2000-10-10T13:55:36-07:00",
+				"http": map[string]any{
+					"request": map[string]any{
+						"id":       "b6a7fd1e-0c3e-4e3b-9d1a-7a8b4c1f5e2d",
+						"method":   "GET",
+						"referrer": "https://example.com/",
+						"body":     map[string]any{"bytes": float64(7)},
+					},
+					"response": map[string]any{
+						"status_code": float64(200),
+						"body":        map[string]any{"bytes": float64(2326)},
+					},
+					"version": "1.1",
+				},
+				"url":        map[string]any{"path": "/users/1", "query": "page=2"},
+				"event":      map[string]any{"duration": float64(1500 * time.Millisecond)},
+				"client":     map[string]any{"ip": "192.0.2.1"},
+				"user_agent": map[string]any{"original": "curl/8.0"},
+				"trace":      map[string]any{"id": "5759e988bd862e3fe1be46a994272793"},
+				"span":       map[string]any{"id": "53995c3f42cd8ad8"},
+				"user":       map[string]any{"id": "alice"},
+			},
+		},
+		{
+			name:   "success:gcp",
+			format: GCPFormat{ProjectID: "my-project"},
+			wantJSON: map[string]any{
+				"httpRequest": map[string]any{
+					"requestMethod": "GET",
+					"requestUrl":    "/users/1?page=2",
+					"requestSize":   "7",
+					"status":        float64(200),
+					"responseSize":  "2326",
+					"latency":       "1.5s",
+					"userAgent":     "curl/8.0",
+					"remoteIp":      "192.0.2.1",
+					"referer":       "https://example.com/",
+					"protocol":      "HTTP/1.1",
+				},
+				"logging.googleapis.com/trace":  "projects/my-project/traces/5759e988bd862e3fe1be46a994272793",
+				"logging.googleapis.com/spanId": "53995c3f42cd8ad8",
+			},
+		},
+		{
+			name:   "success:gcp-without-trace",
+			format: GCPFormat{},
+			rd: func(rd *RequestData) {
+				rd.TraceID = ""
+				rd.UserAgent = ""
+				rd.Referer = ""
+			},
+			wantJSON: map[string]any{
+				"httpRequest": map[string]any{
+					"requestMethod": "GET",
+					"requestUrl":    "/users/1?page=2",
+					"requestSize":   "7",
+					"status":        float64(200),
+					"responseSize":  "2326",
+					"latency":       "1.5s",
+					"remoteIp":      "192.0.2.1",
+					"protocol":      "HTTP/1.1",
+				},
+			},
+		},
+		{
+			name:   "success:aws",
+			format: AWSFormat{},
+			wantJSON: map[string]any{
+				"requestId":        "b6a7fd1e-0c3e-4e3b-9d1a-7a8b4c1f5e2d",
+				"requestTime":      "10/Oct/2000:13:55:36 -0700",
+				"requestTimeEpoch": float64(971211336000),
+				"httpMethod":       "GET",
+				"path":             "/users/1",
+				"protocol":         "HTTP/1.1",
+				"status":           float64(200),
+				"requestLength":    float64(7),
+				"responseLength":   float64(2326),
+				"responseLatency":  float64(1500),
+				"ip":               "192.0.2.1",
+				"resourcePath":     "/users/{id}",
+				"userAgent":        "curl/8.0",
+				"principalId":      "alice",
+				"xrayTraceId":      "1-5759e988-bd862e3fe1be46a994272793",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd := testRequestData()
+			if tt.rd != nil {
+				tt.rd(&rd)
+			}
+			b := &bytes.Buffer{}
+			require.NoError(t, tt.format.Write(b, rd))
+			require.True(t, strings.HasSuffix(b.String(), "\n"))
+			require.Equal(t, 1, strings.Count(b.String(), "\n"))
+
+			if tt.wantJSON == nil {
+				assert.Equal(t, tt.wantLine+"\n", b.String())
+				return
+			}
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(b.Bytes(), &got))
+			assert.Equal(t, tt.wantJSON, got)
+		})
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	logs := &bytes.Buffer{}
+	h := AccessLogMiddleware(logs, CombinedLogFormat{}, WithSkippedPaths("/healthz"))(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/users?dry_run=1", strings.NewReader("{}"))
+	r.Header.Set("User-Agent", "test agent")
+	r.RemoteAddr = "192.0.2.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), r.WithContext(net.WithPrincipal(r.Context(), "alice")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	line := logs.String()
+	assert.Equal(t, 1, strings.Count(line, "\n"))
+	assert.True(t, strings.HasPrefix(line, "192.0.2.1 - alice ["), line)
+	assert.True(t, strings.HasSuffix(line, `] "POST /users?dry_run=1 HTTP/1.1" 201 7 "-" "test_agent"`+"\n"), line)
+}
+
+func TestLoggingMiddleware_WithAccessLogFormat(t *testing.T) {
+	logs := &bytes.Buffer{}
+	l := slog.New(slog.NewJSONHandler(logs, nil))
+	h := LoggingMiddleware(l, WithAccessLogFormat(GCPFormat{}))(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}),
+	)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "request processed", entry["msg"])
+	assert.NotContains(t, entry, "request")
+	require.IsType(t, map[string]any{}, entry["httpRequest"])
+	httpRequest := entry["httpRequest"].(map[string]any)
+	assert.Equal(t, float64(http.StatusBadGateway), httpRequest["status"])
+	assert.Equal(t, "/users", httpRequest["requestUrl"])
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+
+	neterrors "go.strv.io/net/errors"
+)
+
+var kindStatusCodes = map[neterrors.Kind]int{
+	neterrors.KindUnknown:          http.StatusInternalServerError,
+	neterrors.KindInternal:         http.StatusInternalServerError,
+	neterrors.KindInvalidArgument:  http.StatusBadRequest,
+	neterrors.KindNotFound:         http.StatusNotFound,
+	neterrors.KindAlreadyExists:    http.StatusConflict,
+	neterrors.KindConflict:         http.StatusConflict,
+	neterrors.KindUnauthenticated:  http.StatusUnauthorized,
+	neterrors.KindPermissionDenied: http.StatusForbidden,
+	neterrors.KindTooManyRequests:  http.StatusTooManyRequests,
+	neterrors.KindUnimplemented:    http.StatusNotImplemented,
+	neterrors.KindUnavailable:      http.StatusServiceUnavailable,
+	neterrors.KindDeadlineExceeded: http.StatusGatewayTimeout,
+}
+
+// StatusCodeFromKind returns the HTTP status code of errors of the kind, 500 Internal Server Error for unknown kinds.
+func StatusCodeFromKind(kind neterrors.Kind) int {
+	if statusCode, ok := kindStatusCodes[kind]; ok {
+		return statusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorStatusCode returns the HTTP status code of the kind of the application error in the chain of err
+// (see neterrors.Error), 500 Internal Server Error if there is none.
+func ErrorStatusCode(err error) int {
+	return StatusCodeFromKind(neterrors.KindOf(err))
+}
+
+// WithAppError sets err as the error of the response. If the chain of err contains an application error
+// (see neterrors.Error), its code and public message are written, internal details are not.
+func WithAppError(err error) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.Err = err
+		if appErr, ok := neterrors.AsError(err); ok {
+			o.ErrCode = appErr.Code
+			o.ErrMessage = appErr.Message
+		}
+	}
+}
+
+// WriteAppErrorResponse writes the error response with the status code, code and public message
+// of the application error in the chain of err, see ErrorStatusCode and WithAppError.
+func WriteAppErrorResponse(w http.ResponseWriter, err error, opts ...ErrorResponseOption) error {
+	return WriteErrorResponse(w, ErrorStatusCode(err), append([]ErrorResponseOption{WithAppError(err)}, opts...)...)
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	neterrors "go.strv.io/net/errors"
+	"go.strv.io/net/internal"
+)
+
+func TestWriteAppErrorResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "success:not-found",
+			err:        neterrors.New(neterrors.KindNotFound, "ERR_USER_NOT_FOUND", "user not found", neterrors.WithDetail("userId", "1")),
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"errorCode":"ERR_USER_NOT_FOUND","errorMessage":"user not found"}` + "\n",
+		},
+		{
+			name:       "success:wrapped",
+			err:        fmt.Errorf("creating user: %w", neterrors.New(neterrors.KindAlreadyExists, "", "")),
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"errorCode":"ERR_ALREADY_EXISTS"}` + "\n",
+		},
+		{
+			name:       "success:unavailable",
+			err:        neterrors.New(neterrors.KindUnavailable, "", "try again later"),
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"errorCode":"ERR_UNAVAILABLE","errorMessage":"try again later"}` + "\n",
+		},
+		{
+			name:       "success:other-error",
+			err:        errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"errorCode":"ERR_UNKNOWN"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rw := NewResponseWriter(w, internal.NewNopLogger())
+
+			require.NoError(t, WriteAppErrorResponse(rw, tt.err))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, tt.err, rw.ErrorObject())
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.strv.io/net/internal"
+)
+
+const (
+	defaultMaxLoggedBodySize = 4 << 10
+
+	// RedactedValue replaces values of redacted JSON fields, form fields and headers.
+	RedactedValue = "[REDACTED]"
+)
+
+type BodyLoggingMiddlewareOptions struct {
+	maxBodySize   int
+	redactFields  [][]string
+	redactHeaders map[string]struct{}
+	logger        *slog.Logger
+}
+
+type BodyLoggingMiddlewareOption func(*BodyLoggingMiddlewareOptions)
+
+// WithMaxLoggedBodySize sets the maximum number of captured bytes of each body. Default is 4 KiB.
+// The handler still reads and writes whole bodies, only the logged part is truncated.
+func WithMaxLoggedBodySize(size int) BodyLoggingMiddlewareOption {
+	return func(o *BodyLoggingMiddlewareOptions) {
+		o.maxBodySize = size
+	}
+}
+
+// WithRedactedFields sets JSON and form fields whose values are replaced by RedactedValue.
+// A name without dots (e.g. "token") matches the field at any depth whose name ends with it, ignoring underscores
+// and hyphens (e.g. "access_token", "refreshToken"). A path with dots (e.g. "user.card.number") matches the field
+// from the root of the document exactly. Arrays are transparent in paths and "*" matches any field name.
+// Matching is case-insensitive. Default is "password", "token", "secret", "apikey" and "authorization".
+func WithRedactedFields(paths ...string) BodyLoggingMiddlewareOption {
+	return func(o *BodyLoggingMiddlewareOptions) {
+		o.redactFields = make([][]string, 0, len(paths))
+		for _, p := range paths {
+			o.redactFields = append(o.redactFields, strings.Split(strings.ToLower(p), "."))
+		}
+	}
+}
+
+// WithRedactedHeaders sets request and response headers whose values are replaced by RedactedValue.
+// Default is Authorization, Proxy-Authorization, Cookie and Set-Cookie.
+func WithRedactedHeaders(names ...string) BodyLoggingMiddlewareOption {
+	return func(o *BodyLoggingMiddlewareOptions) {
+		o.redactHeaders = make(map[string]struct{}, len(names))
+		for _, n := range names {
+			o.redactHeaders[http.CanonicalHeaderKey(n)] = struct{}{}
+		}
+	}
+}
+
+// WithBodyLoggingLogger sets a logger for the response writer created by the middleware.
+func WithBodyLoggingLogger(l *slog.Logger) BodyLoggingMiddlewareOption {
+	return func(o *BodyLoggingMiddlewareOptions) {
+		o.logger = l
+	}
+}
+
+// BodyLoggingMiddleware captures headers and bodies of the request and the response, and adds them
+// to the request group logged by LoggingMiddleware (request_headers, request_body, response_headers, response_body).
+// It is intended for debugging, as it makes the logs considerably larger. LoggingMiddleware has to be registered
+// before this middleware.
+//
+// The request body is captured while the handler reads it, so the handler is not affected. At most
+// WithMaxLoggedBodySize bytes of each body are captured, truncated bodies have request_body_truncated
+// or response_body_truncated set. Only textual bodies (text/*, JSON, XML, YAML and forms) are logged.
+// Values of sensitive headers and JSON or form fields are redacted (see WithRedactedHeaders and WithRedactedFields),
+// and truncated JSON bodies, which cannot be redacted reliably, are not logged at all.
+func BodyLoggingMiddleware(opts ...BodyLoggingMiddlewareOption) func(http.Handler) http.Handler {
+	options := BodyLoggingMiddlewareOptions{
+		maxBodySize: defaultMaxLoggedBodySize,
+		logger:      internal.NewNopLogger(),
+	}
+	WithRedactedFields("password", "token", "secret", "apikey", "authorization")(&options)
+	WithRedactedHeaders(Header.Authorization, "Proxy-Authorization", "Cookie", "Set-Cookie")(&options)
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw, ok := w.(*ResponseWriter)
+			if !ok {
+				rw = NewResponseWriter(w, options.logger)
+			}
+
+			requestBody := &cappedBuffer{limit: options.maxBodySize}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &teeReadCloser{Reader: io.TeeReader(r.Body, requestBody), Closer: r.Body}
+			}
+			responseBody := &cappedBuffer{limit: options.maxBodySize}
+			rw.TeeBody(responseBody)
+
+			next.ServeHTTP(rw, r)
+
+			attrs := []slog.Attr{options.headersAttr("request_headers", r.Header)}
+			attrs = append(attrs, options.bodyAttrs("request_body", r.Header.Get(Header.ContentType), requestBody)...)
+			attrs = append(attrs, options.headersAttr("response_headers", rw.Header()))
+			attrs = append(attrs, options.bodyAttrs("response_body", rw.Header().Get(Header.ContentType), responseBody)...)
+			rw.AddRequestLogAttrs(attrs...)
+		})
+	}
+}
+
+func (o BodyLoggingMiddlewareOptions) headersAttr(key string, h http.Header) slog.Attr {
+	attrs := make([]any, 0, len(h))
+	for name, values := range h {
+		value := strings.Join(values, ", ")
+		if _, ok := o.redactHeaders[http.CanonicalHeaderKey(name)]; ok {
+			value = RedactedValue
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.Group(key, attrs...)
+}
+
+func (o BodyLoggingMiddlewareOptions) bodyAttrs(key, contentType string, b *cappedBuffer) []slog.Attr {
+	if b.Len() == 0 && !b.truncated {
+		return nil
+	}
+	var attrs []slog.Attr
+	if b.truncated {
+		attrs = append(attrs, slog.Bool(key+"_truncated", true))
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case isJSONMediaType(mediaType):
+		if b.truncated {
+			return attrs
+		}
+		if redacted, ok := o.redactJSON(b.Bytes()); ok {
+			attrs = append(attrs, slog.String(key, string(redacted)))
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(b.String()); err == nil {
+			for name := range values {
+				if o.isRedactedField([]string{strings.ToLower(name)}) {
+					values[name] = []string{RedactedValue}
+				}
+			}
+			attrs = append(attrs, slog.String(key, values.Encode()))
+		}
+	case isTextMediaType(mediaType):
+		attrs = append(attrs, slog.String(key, b.String()))
+	}
+	return attrs
+}
+
+func (o BodyLoggingMiddlewareOptions) redactJSON(data []byte) ([]byte, bool) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, false
+	}
+	redacted, err := json.Marshal(o.redactValue(v, nil))
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+func (o BodyLoggingMiddlewareOptions) redactValue(v any, path []string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			childPath := append(path[:len(path):len(path)], strings.ToLower(k))
+			if o.isRedactedField(childPath) {
+				t[k] = RedactedValue
+				continue
+			}
+			t[k] = o.redactValue(child, childPath)
+		}
+	case []any:
+		for i, child := range t {
+			t[i] = o.redactValue(child, path)
+		}
+	}
+	return v
+}
+
+func (o BodyLoggingMiddlewareOptions) isRedactedField(path []string) bool {
+	for _, pattern := range o.redactFields {
+		if len(pattern) == 1 {
+			if matchNameSuffix(pattern[0], path[len(path)-1]) {
+				return true
+			}
+			continue
+		}
+		if len(pattern) != len(path) {
+			continue
+		}
+		matched := true
+		for i := range pattern {
+			if !matchPathSegment(pattern[i], path[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPathSegment(pattern, segment string) bool {
+	return pattern == "*" || pattern == segment
+}
+
+// matchNameSuffix reports whether the field name ends with the pattern, ignoring underscores and hyphens.
+func matchNameSuffix(pattern, name string) bool {
+	if pattern == "*" {
+		return true
+	}
+	return strings.HasSuffix(fieldNameReplacer.Replace(name), fieldNameReplacer.Replace(pattern))
+}
+
+var fieldNameReplacer = strings.NewReplacer("_", "", "-", "")
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == string(ApplicationJSON) || mediaType == string(TextJSON) || strings.HasSuffix(mediaType, "+json")
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+xml") || strings.HasSuffix(mediaType, "+yaml") {
+		return true
+	}
+	switch ContentType(mediaType) {
+	case ApplicationXML, ApplicationYAML, ApplicationXYAML:
+		return true
+	default:
+		return false
+	}
+}
+
+// cappedBuffer keeps at most limit bytes and records if more bytes were written.
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); remaining < len(p) {
+		b.truncated = true
+		b.Buffer.Write(p[:max(remaining, 0)])
+		return len(p), nil
+	}
+	b.Buffer.Write(p)
+	return len(p), nil
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
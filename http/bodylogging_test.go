@@ -0,0 +1,132 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLoggingMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []BodyLoggingMiddlewareOption
+		contentType string
+		body        string
+		respType    string
+		respBody    string
+		want        map[string]any
+		wantAbsent  []string
+	}{
+		{
+			name:        "success:json-redacted",
+			opts:        []BodyLoggingMiddlewareOption{WithRedactedFields("password", "card.number", "items.secret", "*.exp")},
+			contentType: "application/json",
+			body:        `{"email":"a@b.c","Password":"p","card":{"number":"4242","exp":"12/30"},"items":[{"secret":1,"id":2}]}`,
+			respType:    "application/problem+json; charset=utf-8",
+			respBody:    `{"user":{"password":"x","token":"y"}}`,
+			want: map[string]any{
+				"request_body":  `{"Password":"[REDACTED]","card":{"exp":"[REDACTED]","number":"[REDACTED]"},"email":"a@b.c","items":[{"id":2,"secret":"[REDACTED]"}]}`,
+				"response_body": `{"user":{"password":"[REDACTED]","token":"y"}}`,
+			},
+		},
+		{
+			name:        "success:default-fields",
+			contentType: "application/json",
+			body:        `{"user":{"token":"t","name":"n"}}`,
+			respType:    "text/plain",
+			respBody:    "ok",
+			want: map[string]any{
+				"request_body":  `{"user":{"name":"n","token":"[REDACTED]"}}`,
+				"response_body": "ok",
+			},
+		},
+		{
+			name:        "success:default-fields-suffix",
+			contentType: "application/json",
+			body:        `{"refresh_token":"r","client_secret":"s","api_key":"k","newPassword":"p","tokens":1}`,
+			respType:    "text/plain",
+			respBody:    "ok",
+			want: map[string]any{
+				"request_body": `{"api_key":"[REDACTED]","client_secret":"[REDACTED]","newPassword":"[REDACTED]","refresh_token":"[REDACTED]","tokens":1}`,
+			},
+		},
+		{
+			name:        "success:form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=n&password=p",
+			want: map[string]any{
+				"request_body": "name=n&password=%5BREDACTED%5D",
+			},
+			wantAbsent: []string{"response_body"},
+		},
+		{
+			name:        "success:truncated",
+			opts:        []BodyLoggingMiddlewareOption{WithMaxLoggedBodySize(4)},
+			contentType: "application/json",
+			body:        `{"password":"p"}`,
+			respType:    "text/plain",
+			respBody:    "0123456789",
+			want: map[string]any{
+				"request_body_truncated":  true,
+				"response_body":           "0123",
+				"response_body_truncated": true,
+			},
+			wantAbsent: []string{"request_body"},
+		},
+		{
+			name:        "success:binary-skipped",
+			contentType: "application/octet-stream",
+			body:        "\x00\x01",
+			respType:    "image/png",
+			respBody:    "\x89PNG",
+			wantAbsent:  []string{"request_body", "response_body"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			l := slog.New(slog.NewJSONHandler(logs, nil))
+
+			var handlerBody string
+			h := LoggingMiddleware(l)(BodyLoggingMiddleware(tt.opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				handlerBody = string(b)
+				if tt.respType != "" {
+					w.Header().Set(Header.ContentType, tt.respType)
+					_, _ = w.Write([]byte(tt.respBody))
+				}
+			})))
+
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body))
+			r.Header.Set(Header.ContentType, tt.contentType)
+			r.Header.Set(Header.Authorization, "Bearer secret")
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tt.body, handlerBody)
+
+			var entry struct {
+				Request map[string]any `json:"request"`
+			}
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+			for k, v := range tt.want {
+				assert.Equal(t, v, entry.Request[k], k)
+			}
+			for _, k := range tt.wantAbsent {
+				assert.NotContains(t, entry.Request, k)
+			}
+			assert.Equal(t, map[string]any{
+				"Authorization": RedactedValue,
+				"Content-Type":  tt.contentType,
+			}, entry.Request["request_headers"])
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	httpx "go.strv.io/net/http"
+	"go.strv.io/net/internal"
+)
+
+const (
+	defaultMaxEntries   = 1000
+	defaultMaxEntrySize = 1 << 20
+
+	logFieldName = "cache"
+)
+
+// Status describes how the request was served. It is logged by httpx.LoggingMiddleware under the "cache" key.
+type Status string
+
+const (
+	// StatusHit means the response was served from the cache.
+	StatusHit Status = "hit"
+	// StatusStale means a stale response was served from the cache and revalidated in the background.
+	StatusStale Status = "stale"
+	// StatusMiss means the handler was called.
+	StatusMiss Status = "miss"
+	// StatusCoalesced means the request waited for a concurrent identical request and got its response.
+	StatusCoalesced Status = "coalesced"
+	// StatusBypass means the request is not cacheable (e.g. POST request).
+	StatusBypass Status = "bypass"
+)
+
+// Cache is an in-process HTTP response cache.
+//
+// Responses are stored only if the handler allows it by Cache-Control header with max-age (or s-maxage) directive,
+// and not if it contains no-store, no-cache or private directive or the response sets a cookie.
+// Responses to requests with Authorization header are stored only if Cache-Control contains public, s-maxage
+// or must-revalidate directive, see RFC 9111, section 3.5. Responses with Vary header are stored only if all
+// the listed request headers are part of the cache key (see WithVary).
+// Directive stale-while-revalidate allows serving stale responses while the handler is called in the background.
+type Cache struct {
+	entries      *lru
+	maxEntrySize int
+	vary         []string
+	logger       *slog.Logger
+	now          func() time.Time
+
+	mu       sync.Mutex
+	inflight map[string]*flight
+}
+
+// flight is a handler call that concurrent identical requests wait for.
+type flight struct {
+	done  chan struct{}
+	entry *entry
+}
+
+// Option configures the Cache.
+type Option func(*Cache)
+
+// WithMaxEntries limits the number of stored responses. Default is 1000.
+func WithMaxEntries(n int) Option {
+	return func(c *Cache) {
+		c.entries = newLRU(n)
+	}
+}
+
+// WithMaxEntrySize sets the maximum body size of a stored response. Larger responses are not stored.
+// Default is 1 MiB.
+func WithMaxEntrySize(n int) Option {
+	return func(c *Cache) {
+		c.maxEntrySize = n
+	}
+}
+
+// WithVary sets request headers that are part of the cache key (e.g. Accept-Language).
+func WithVary(headers ...string) Option {
+	return func(c *Cache) {
+		c.vary = make([]string, 0, len(headers))
+		for _, h := range headers {
+			c.vary = append(c.vary, http.CanonicalHeaderKey(h))
+		}
+	}
+}
+
+// WithLogger sets a logger for the response writers created by the cache.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Cache) {
+		c.logger = l
+	}
+}
+
+// New creates an empty Cache.
+func New(opts ...Option) *Cache {
+	c := &Cache{
+		entries:      newLRU(defaultMaxEntries),
+		maxEntrySize: defaultMaxEntrySize,
+		logger:       internal.NewNopLogger(),
+		now:          time.Now,
+		inflight:     map[string]*flight{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Middleware serves GET and HEAD requests from the cache.
+//
+// On a miss, concurrent identical requests are coalesced, so the handler is called only once
+// and the other requests get the same response.
+func (c *Cache) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				setStatus(w, StatusBypass)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := c.key(r.Method, r.URL.EscapedPath(), r.URL.RawQuery, r.Header)
+			now := c.now()
+			if e, ok := c.entries.get(key); ok {
+				if e.fresh(now) {
+					setStatus(w, StatusHit)
+					c.serve(w, r, e)
+					return
+				}
+				if e.usableStale(now) {
+					setStatus(w, StatusStale)
+					c.serve(w, r, e)
+					c.revalidate(key, r, next)
+					return
+				}
+			}
+
+			c.mu.Lock()
+			f, ok := c.inflight[key]
+			if ok {
+				c.mu.Unlock()
+				select {
+				case <-f.done:
+				case <-r.Context().Done():
+					return
+				}
+				if f.entry != nil {
+					setStatus(w, StatusCoalesced)
+					c.serve(w, r, f.entry)
+					return
+				}
+				setStatus(w, StatusMiss)
+				next.ServeHTTP(w, r)
+				return
+			}
+			f = &flight{done: make(chan struct{})}
+			c.inflight[key] = f
+			c.mu.Unlock()
+
+			setStatus(w, StatusMiss)
+			c.fill(key, f, w, r, next)
+		})
+	}
+}
+
+// fill calls the handler, stores the response if cacheable and releases the waiting requests.
+func (c *Cache) fill(key string, f *flight, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.inflight, key)
+		c.mu.Unlock()
+		close(f.done)
+	}()
+
+	rw, ok := w.(*httpx.ResponseWriter)
+	if !ok {
+		rw = httpx.NewResponseWriter(w, c.logger)
+	}
+	body := &bytes.Buffer{}
+	rw.TeeBody(body)
+
+	next.ServeHTTP(rw, r)
+
+	if e := c.newEntry(key, r, rw, body.Bytes()); e != nil {
+		c.entries.add(e)
+		f.entry = e
+	}
+}
+
+// revalidate calls the handler in the background, unless the revalidation of the key is already in progress.
+// A panic of the handler is logged, the in-flight marker of the key is released by fill.
+func (c *Cache) revalidate(key string, r *http.Request, next http.Handler) {
+	c.mu.Lock()
+	if _, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		return
+	}
+	f := &flight{done: make(chan struct{})}
+	c.inflight[key] = f
+	c.mu.Unlock()
+
+	br := r.Clone(context.WithoutCancel(r.Context()))
+	go func() {
+		defer func() {
+			if re := recover(); re != nil {
+				c.logger.LogAttrs(br.Context(), slog.LevelError, "cache revalidation panic recover",
+					slog.String("key", key),
+					slog.Any("error", re),
+				)
+			}
+		}()
+		w := &discardResponseWriter{header: http.Header{}}
+		c.fill(key, f, httpx.NewResponseWriter(w, c.logger), br, next)
+	}()
+}
+
+func (c *Cache) serve(w http.ResponseWriter, r *http.Request, e *entry) {
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = v
+	}
+	h.Set("Age", strconv.Itoa(int(c.now().Sub(e.storedAt).Seconds())))
+	w.WriteHeader(e.statusCode)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.body)
+	}
+}
+
+// newEntry returns an entry for the response, or nil if the response must not be stored.
+func (c *Cache) newEntry(key string, r *http.Request, rw *httpx.ResponseWriter, body []byte) *entry {
+	if rw.PanicObject() != nil || !cacheableStatus(rw.StatusCode()) || len(body) > c.maxEntrySize {
+		return nil
+	}
+	header := rw.Header().Clone()
+	if header.Get("Set-Cookie") != "" {
+		return nil
+	}
+	cc := parseCacheControl(header.Get("Cache-Control"))
+	if cc.noStore || cc.noCache || cc.private || cc.maxAge <= 0 && cc.staleWhileRevalidate <= 0 {
+		return nil
+	}
+	if r.Header.Get(httpx.Header.Authorization) != "" && !cc.sharedWithAuthorization() {
+		return nil
+	}
+	if !c.coversVary(header) {
+		return nil
+	}
+	header.Del(httpx.Header.XRequestID)
+
+	now := c.now()
+	expiresAt := now.Add(cc.maxAge)
+	return &entry{
+		key:        key,
+		statusCode: rw.StatusCode(),
+		header:     header,
+		body:       body,
+		storedAt:   now,
+		expiresAt:  expiresAt,
+		staleUntil: expiresAt.Add(cc.staleWhileRevalidate),
+	}
+}
+
+// coversVary reports whether the cache key contains all request headers listed in Vary header of the response.
+// Responses varying by other headers (or by "*") must not be stored, as they would be served to other clients.
+func (c *Cache) coversVary(header http.Header) bool {
+	for _, value := range header.Values(httpx.Header.Vary) {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if name == "*" || !slices.Contains(c.vary, name) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// key returns a cache key from method, path, query with sorted parameters and values of configured Vary headers.
+func (c *Cache) key(method, path, rawQuery string, h http.Header) string {
+	var b strings.Builder
+	b.WriteString(method)
+	b.WriteByte(' ')
+	b.WriteString(path)
+	if query := normalizeQuery(rawQuery); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+	for _, name := range c.vary {
+		b.WriteByte('\n')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(h.Values(name), ","))
+	}
+	return b.String()
+}
+
+func setStatus(w http.ResponseWriter, s Status) {
+	if rw, ok := httpx.UnwrapResponseWriter(w); ok {
+		rw.AddLogAttrs(slog.String(logFieldName, string(s)))
+	}
+}
+
+func cacheableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusOK,
+		http.StatusNonAuthoritativeInfo,
+		http.StatusNoContent,
+		http.StatusMovedPermanently,
+		http.StatusNotFound,
+		http.StatusGone:
+		return true
+	default:
+		return false
+	}
+}
+
+// discardResponseWriter is used for the background revalidation, when there is no client to write to.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header {
+	return d.header
+}
+
+func (d *discardResponseWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (d *discardResponseWriter) WriteHeader(int) {}
@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpx "go.strv.io/net/http"
+)
+
+func countingHandler(calls *atomic.Int32, cacheControl string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if cacheControl != "" {
+			w.Header().Set("Cache-Control", cacheControl)
+		}
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language") + strconv.Itoa(int(n))))
+	})
+}
+
+func TestCache_Middleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		requests     []string
+		headers      []string
+		wantBodies   []string
+	}{
+		{
+			name:         "success:hit",
+			cacheControl: "public, max-age=60",
+			requests:     []string{"/users?page=1&per_page=10", "/users?per_page=10&page=1"},
+			wantBodies:   []string{"1", "1"},
+		},
+		{
+			name:         "success:different-query",
+			cacheControl: "max-age=60",
+			requests:     []string{"/users?page=1", "/users?page=2"},
+			wantBodies:   []string{"1", "2"},
+		},
+		{
+			name:         "success:vary",
+			cacheControl: "max-age=60",
+			requests:     []string{"/users", "/users", "/users"},
+			headers:      []string{"en", "cs", "en"},
+			wantBodies:   []string{"en1", "cs2", "en1"},
+		},
+		{
+			name:         "success:no-store",
+			cacheControl: "no-store",
+			requests:     []string{"/users", "/users"},
+			wantBodies:   []string{"1", "2"},
+		},
+		{
+			name:         "success:private",
+			cacheControl: "private, max-age=60",
+			requests:     []string{"/users", "/users"},
+			wantBodies:   []string{"1", "2"},
+		},
+		{
+			name:       "success:without-cache-control",
+			requests:   []string{"/users", "/users"},
+			wantBodies: []string{"1", "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := &atomic.Int32{}
+			h := New(WithVary("Accept-Language")).Middleware()(countingHandler(calls, tt.cacheControl))
+
+			for i, url := range tt.requests {
+				r := httptest.NewRequest(http.MethodGet, url, nil)
+				if tt.headers != nil {
+					r.Header.Set("Accept-Language", tt.headers[i])
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, r)
+				assert.Equal(t, tt.wantBodies[i], rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	calls := &atomic.Int32{}
+	c := New()
+	now := &atomic.Int64{}
+	now.Store(time.Now().UnixNano())
+	c.now = func() time.Time { return time.Unix(0, now.Load()) }
+	h := c.Middleware()(countingHandler(calls, "max-age=10, stale-while-revalidate=60"))
+
+	serve := func() string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "1", serve())
+	now.Add(int64(30 * time.Second))
+	assert.Equal(t, "1", serve())
+	require.Eventually(t, func() bool {
+		return serve() == "2"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestCache_Coalescing(t *testing.T) {
+	calls := &atomic.Int32{}
+	release := make(chan struct{})
+	h := New().Middleware()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("report"))
+	}))
+
+	const requests = 10
+	wg := sync.WaitGroup{}
+	bodies := make([]string, requests)
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+			bodies[i] = rec.Body.String()
+		}()
+	}
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, b := range bodies {
+		assert.Equal(t, "report", b)
+	}
+}
+
+func TestCache_LoggedStatus(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := slog.New(slog.NewJSONHandler(buf, nil))
+	calls := &atomic.Int32{}
+	h := httpx.LoggingMiddleware(l)(New().Middleware()(countingHandler(calls, "max-age=60")))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Contains(t, buf.String(), `"cache":"miss"`)
+	buf.Reset()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Contains(t, buf.String(), `"cache":"hit"`)
+}
+
+func TestLRU_Eviction(t *testing.T) {
+	c := newLRU(2)
+	c.add(&entry{key: "a"})
+	c.add(&entry{key: "b"})
+	_, _ = c.get("a")
+	c.add(&entry{key: "c"})
+
+	_, ok := c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("a")
+	assert.True(t, ok)
+	_, ok = c.get("c")
+	assert.True(t, ok)
+}
+
+func TestCache_Authorization(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		wantBodies   []string
+	}{
+		{
+			name:         "success:not-stored",
+			cacheControl: "max-age=60",
+			wantBodies:   []string{"alice1", "bob2"},
+		},
+		{
+			name:         "success:public",
+			cacheControl: "public, max-age=60",
+			wantBodies:   []string{"alice1", "alice1"},
+		},
+		{
+			name:         "success:s-maxage",
+			cacheControl: "s-maxage=60",
+			wantBodies:   []string{"alice1", "alice1"},
+		},
+		{
+			name:         "success:must-revalidate",
+			cacheControl: "max-age=60, must-revalidate",
+			wantBodies:   []string{"alice1", "alice1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := &atomic.Int32{}
+			h := New().Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				_, _ = w.Write([]byte(r.Header.Get(httpx.Header.Authorization) + strconv.Itoa(int(n))))
+			}))
+
+			for i, authorization := range []string{"alice", "bob"} {
+				r := httptest.NewRequest(http.MethodGet, "/me", nil)
+				r.Header.Set(httpx.Header.Authorization, authorization)
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, r)
+				assert.Equal(t, tt.wantBodies[i], rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCache_RevalidationPanic(t *testing.T) {
+	calls := &atomic.Int32{}
+	c := New()
+	now := &atomic.Int64{}
+	now.Store(time.Now().UnixNano())
+	c.now = func() time.Time { return time.Unix(0, now.Load()) }
+	h := c.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := calls.Add(1)
+		if n == 2 {
+			panic("revalidation failed")
+		}
+		w.Header().Set("Cache-Control", "max-age=10, stale-while-revalidate=60")
+		_, _ = w.Write([]byte(strconv.Itoa(int(n))))
+	}))
+
+	serve := func() string {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+		return rec.Body.String()
+	}
+
+	assert.Equal(t, "1", serve())
+	now.Add(int64(30 * time.Second))
+	assert.Equal(t, "1", serve())
+	require.Eventually(t, func() bool {
+		return serve() == "3"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCache_ResponseVary(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []Option
+		wantBodies []string
+	}{
+		{
+			name:       "success:vary-not-in-key",
+			wantBodies: []string{`{"n":1}` + "\n", `<n>2</n>`},
+		},
+		{
+			name:       "success:vary-in-key",
+			opts:       []Option{WithVary("Accept")},
+			wantBodies: []string{`{"n":1}` + "\n", `<n>2</n>`, `{"n":1}` + "\n"},
+		},
+	}
+	type response struct {
+		XMLName struct{} `json:"-" xml:"n"`
+		N       int32    `json:"n" xml:",chardata"`
+	}
+	accepts := []string{"application/json", "application/xml", "application/json"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := &atomic.Int32{}
+			h := New(tt.opts...).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				_ = httpx.WriteResponse(w, response{N: calls.Add(1)}, http.StatusOK, httpx.WithNegotiation(r))
+			}))
+
+			for i, want := range tt.wantBodies {
+				r := httptest.NewRequest(http.MethodGet, "/users", nil)
+				r.Header.Set("Accept", accepts[i])
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, r)
+				assert.Contains(t, rec.Body.String(), want)
+			}
+		})
+	}
+}
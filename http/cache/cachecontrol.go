@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cacheControl contains response Cache-Control directives relevant for a shared cache.
+type cacheControl struct {
+	noStore              bool
+	noCache              bool
+	private              bool
+	public               bool
+	mustRevalidate       bool
+	sMaxAge              bool
+	maxAge               time.Duration
+	staleWhileRevalidate time.Duration
+}
+
+// parseCacheControl parses the Cache-Control header value. s-maxage takes precedence over max-age.
+func parseCacheControl(value string) cacheControl {
+	var (
+		cc      cacheControl
+		sMaxAge = -1
+	)
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		arg = strings.Trim(arg, `"`)
+		switch strings.ToLower(name) {
+		case "no-store":
+			cc.noStore = true
+		case "no-cache":
+			cc.noCache = true
+		case "private":
+			cc.private = true
+		case "public":
+			cc.public = true
+		case "must-revalidate":
+			cc.mustRevalidate = true
+		case "max-age":
+			if seconds, err := strconv.Atoi(arg); err == nil {
+				cc.maxAge = time.Duration(seconds) * time.Second
+			}
+		case "s-maxage":
+			if seconds, err := strconv.Atoi(arg); err == nil {
+				sMaxAge = seconds
+			}
+		case "stale-while-revalidate":
+			if seconds, err := strconv.Atoi(arg); err == nil {
+				cc.staleWhileRevalidate = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if sMaxAge >= 0 {
+		cc.sMaxAge = true
+		cc.maxAge = time.Duration(sMaxAge) * time.Second
+	}
+	return cc
+}
+
+// sharedWithAuthorization reports whether the response to a request with Authorization header
+// can be stored by a shared cache, see RFC 9111, section 3.5.
+func (cc cacheControl) sharedWithAuthorization() bool {
+	return cc.public || cc.sMaxAge || cc.mustRevalidate
+}
+
+// normalizeQuery sorts the query parameters by key, so the order of parameters does not affect the cache key.
+// Order of values of the same key is kept, as it may be significant.
+func normalizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	return values.Encode()
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// entry is a stored response.
+type entry struct {
+	key        string
+	statusCode int
+	header     http.Header
+	body       []byte
+	storedAt   time.Time
+	// expiresAt is the time after which the entry is stale.
+	expiresAt time.Time
+	// staleUntil is the time until which the stale entry can be served while it is revalidated.
+	staleUntil time.Time
+}
+
+func (e *entry) fresh(now time.Time) bool {
+	return now.Before(e.expiresAt)
+}
+
+func (e *entry) usableStale(now time.Time) bool {
+	return now.Before(e.staleUntil)
+}
+
+// lru is a least recently used cache bounded by the number of entries.
+type lru struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List
+}
+
+func newLRU(maxEntries int) *lru {
+	return &lru{
+		maxEntries: maxEntries,
+		items:      map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (c *lru) get(key string) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*entry), true
+}
+
+func (c *lru) add(e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[e.key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[e.key] = c.order.PushFront(e)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+}
@@ -0,0 +1,128 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	httpx "go.strv.io/net/http"
+)
+
+// structTag is used for field names of MessagePack and CBOR, so the same types can be encoded as JSON and binary.
+const structTag = "json"
+
+// ErrNotProtoMessage is returned by EncodeProtobuf and DecodeProtobuf if the value does not implement proto.Message.
+var ErrNotProtoMessage = errors.New("not a proto.Message")
+
+var (
+	MsgPackEncoder = httpx.Encoder{
+		ContentTypes: []httpx.ContentType{httpx.ApplicationMsgPack, httpx.ApplicationXMsgPack},
+		EncodeFunc:   EncodeMsgPack,
+		Binary:       true,
+	}
+	CBOREncoder = httpx.Encoder{
+		ContentTypes: []httpx.ContentType{httpx.ApplicationCBOR},
+		EncodeFunc:   EncodeCBOR,
+		Binary:       true,
+	}
+	ProtobufEncoder = httpx.Encoder{
+		ContentTypes: []httpx.ContentType{httpx.ApplicationProtobuf, httpx.ApplicationXProtobuf},
+		EncodeFunc:   EncodeProtobuf,
+		Binary:       true,
+		CanEncode: func(data any) bool {
+			_, ok := data.(proto.Message)
+			return ok
+		},
+	}
+
+	MsgPackDecoder = httpx.Decoder{
+		ContentTypes: []httpx.ContentType{httpx.ApplicationMsgPack, httpx.ApplicationXMsgPack},
+		DecodeFunc:   DecodeMsgPack,
+	}
+	CBORDecoder = httpx.Decoder{
+		ContentTypes: []httpx.ContentType{httpx.ApplicationCBOR},
+		DecodeFunc:   DecodeCBOR,
+	}
+	ProtobufDecoder = httpx.Decoder{
+		ContentTypes: []httpx.ContentType{httpx.ApplicationProtobuf, httpx.ApplicationXProtobuf},
+		DecodeFunc:   DecodeProtobuf,
+	}
+)
+
+// EncodeMsgPack encodes data as MessagePack. Struct fields are named by json tags.
+func EncodeMsgPack(w http.ResponseWriter, data any) error {
+	e := msgpack.NewEncoder(w)
+	e.SetCustomStructTag(structTag)
+	return e.Encode(data)
+}
+
+// DecodeMsgPack decodes MessagePack read from r into v. Struct fields are named by json tags.
+func DecodeMsgPack(r io.Reader, v any) error {
+	d := msgpack.NewDecoder(r)
+	d.SetCustomStructTag(structTag)
+	return d.Decode(v)
+}
+
+// EncodeCBOR encodes data as CBOR (RFC 8949). Struct fields are named by cbor tags, or json tags if missing.
+func EncodeCBOR(w http.ResponseWriter, data any) error {
+	return cbor.NewEncoder(w).Encode(data)
+}
+
+// DecodeCBOR decodes CBOR read from r into v. Struct fields are named by cbor tags, or json tags if missing.
+func DecodeCBOR(r io.Reader, v any) error {
+	return cbor.NewDecoder(r).Decode(v)
+}
+
+// EncodeProtobuf encodes data in Protocol Buffers wire format. Data has to implement proto.Message,
+// so error responses of WriteErrorResponse cannot be encoded by it. ProtobufEncoder is negotiated only for
+// proto.Message responses.
+func EncodeProtobuf(w http.ResponseWriter, data any) error {
+	m, ok := data.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrNotProtoMessage, data)
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// DecodeProtobuf decodes Protocol Buffers wire format read from r into v, which has to implement proto.Message
+// or be a pointer to it (e.g. **pb.User as passed by signature.WrapHandler), nil message is allocated in that case.
+func DecodeProtobuf(r io.Reader, v any) error {
+	m, ok := protoMessage(v)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, m)
+}
+
+func protoMessage(v any) (proto.Message, bool) {
+	if m, ok := v.(proto.Message); ok {
+		return m, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Pointer {
+		return nil, false
+	}
+	elem := rv.Elem()
+	if !elem.Type().Implements(reflect.TypeFor[proto.Message]()) {
+		return nil, false
+	}
+	if elem.IsNil() {
+		elem.Set(reflect.New(elem.Type().Elem()))
+	}
+	return elem.Interface().(proto.Message), true //nolint:forcetypeassert // checked by Implements
+}
@@ -0,0 +1,151 @@
+package codec_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	httpx "go.strv.io/net/http"
+	"go.strv.io/net/http/codec"
+)
+
+type user struct {
+	Name  string `json:"name"`
+	Group int    `json:"group,omitempty"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		encode httpx.EncodeFunc
+		decode httpx.DecodeFunc
+	}{
+		{name: "success:msgpack", encode: codec.EncodeMsgPack, decode: codec.DecodeMsgPack},
+		{name: "success:cbor", encode: codec.EncodeCBOR, decode: codec.DecodeCBOR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, tt.encode(w, user{Name: "alice", Group: 1}))
+
+			var got user
+			require.NoError(t, tt.decode(w.Body, &got))
+			assert.Equal(t, user{Name: "alice", Group: 1}, got)
+		})
+	}
+}
+
+func TestEncode_JSONTags(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, codec.EncodeMsgPack(w, user{Name: "alice"}))
+	var m map[string]any
+	require.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &m))
+	assert.Equal(t, map[string]any{"name": "alice"}, m)
+
+	w = httptest.NewRecorder()
+	require.NoError(t, codec.EncodeCBOR(w, user{Name: "alice"}))
+	m = nil
+	require.NoError(t, cbor.Unmarshal(w.Body.Bytes(), &m))
+	assert.Equal(t, map[string]any{"name": "alice"}, m)
+}
+
+func TestProtobuf(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, codec.EncodeProtobuf(w, wrapperspb.String("alice")))
+	b, err := proto.Marshal(wrapperspb.String("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, b, w.Body.Bytes())
+
+	got := &wrapperspb.StringValue{}
+	require.NoError(t, codec.DecodeProtobuf(bytes.NewReader(b), got))
+	assert.Equal(t, "alice", got.GetValue())
+
+	var gotPtr *wrapperspb.StringValue
+	require.NoError(t, codec.DecodeProtobuf(bytes.NewReader(b), &gotPtr))
+	assert.Equal(t, "alice", gotPtr.GetValue())
+
+	assert.ErrorIs(t, codec.EncodeProtobuf(httptest.NewRecorder(), user{}), codec.ErrNotProtoMessage)
+	assert.ErrorIs(t, codec.DecodeProtobuf(bytes.NewReader(b), &user{}), codec.ErrNotProtoMessage)
+}
+
+func TestWriteResponse_WithNegotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		data            any
+		wantContentType string
+	}{
+		{name: "success:msgpack", accept: "application/x-msgpack", data: user{Name: "alice"}, wantContentType: "application/x-msgpack"},
+		{name: "success:cbor", accept: "application/cbor", data: user{Name: "alice"}, wantContentType: "application/cbor"},
+		{name: "success:protobuf", accept: "application/protobuf", data: wrapperspb.String("alice"), wantContentType: "application/protobuf"},
+		{name: "success:json-fallback", accept: "application/json", data: user{Name: "alice"}, wantContentType: "application/json; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(httpx.Header.Accept, tt.accept)
+			w := httptest.NewRecorder()
+
+			require.NoError(t, httpx.WriteResponse(w, tt.data, http.StatusOK, httpx.WithNegotiation(
+				r, httpx.JSONEncoder, codec.MsgPackEncoder, codec.CBOREncoder, codec.ProtobufEncoder,
+			)))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get(httpx.Header.ContentType))
+			assert.NotEmpty(t, w.Body.Bytes())
+		})
+	}
+}
+
+func TestWriteResponse_WithNegotiation_NotProtoMessage(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantCode        int
+		wantContentType string
+	}{
+		{name: "success:json", accept: "application/protobuf, application/json;q=0.5", wantCode: http.StatusOK, wantContentType: "application/json; charset=utf-8"},
+		{name: "failure:not-acceptable", accept: "application/protobuf", wantCode: http.StatusNotAcceptable, wantContentType: "application/json; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(httpx.Header.Accept, tt.accept)
+			w := httptest.NewRecorder()
+
+			require.NoError(t, httpx.WriteResponse(w, user{Name: "alice"}, http.StatusOK, httpx.WithNegotiation(
+				r, codec.ProtobufEncoder, httpx.JSONEncoder,
+			)))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get(httpx.Header.ContentType))
+			assert.NotEmpty(t, w.Body.Bytes())
+		})
+	}
+}
+
+func TestWriteErrorResponse_WithErrorNegotiation(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(httpx.Header.Accept, "application/msgpack")
+	w := httptest.NewRecorder()
+
+	require.NoError(t, httpx.WriteErrorResponse(
+		w,
+		http.StatusNotFound,
+		httpx.WithErrorCode("ERR_NOT_FOUND"),
+		httpx.WithErrorNegotiation(r, httpx.JSONEncoder, codec.MsgPackEncoder),
+	))
+
+	assert.Equal(t, "application/msgpack", w.Header().Get(httpx.Header.ContentType))
+	var m map[string]any
+	require.NoError(t, msgpack.Unmarshal(w.Body.Bytes(), &m))
+	assert.Equal(t, map[string]any{"errorCode": "ERR_NOT_FOUND"}, m)
+}
@@ -10,12 +10,20 @@ const UTF8 CharsetType = "utf-8"
 type ContentType string
 
 const (
-	ApplicationJSON  ContentType = "application/json"
-	ApplicationXML   ContentType = "application/xml"
-	ApplicationXYAML ContentType = "application/x-yaml"
-	ApplicationYAML  ContentType = "application/yaml"
+	ApplicationCBOR        ContentType = "application/cbor"
+	ApplicationJSON        ContentType = "application/json"
+	ApplicationMsgPack     ContentType = "application/msgpack"
+	ApplicationNDJSON      ContentType = "application/x-ndjson"
+	ApplicationProblemJSON ContentType = "application/problem+json"
+	ApplicationProtobuf    ContentType = "application/protobuf"
+	ApplicationXML         ContentType = "application/xml"
+	ApplicationXMsgPack    ContentType = "application/x-msgpack"
+	ApplicationXProtobuf   ContentType = "application/x-protobuf"
+	ApplicationXYAML       ContentType = "application/x-yaml"
+	ApplicationYAML        ContentType = "application/yaml"
 
 	TextJSON  ContentType = "text/json"
+	TextPlain ContentType = "text/plain"
 	TextXML   ContentType = "text/xml"
 	TextXYAML ContentType = "text/x-yaml"
 	TextYAML  ContentType = "text/yaml"
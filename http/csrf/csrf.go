@@ -0,0 +1,232 @@
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"go.strv.io/net"
+	httpx "go.strv.io/net/http"
+)
+
+const (
+	tokenSize = 32
+
+	// ErrCodeOriginMismatch is the error code of the response when Origin or Referer header is not allowed.
+	ErrCodeOriginMismatch = "ERR_CSRF_ORIGIN_MISMATCH"
+	// ErrCodeTokenMissing is the error code of the response when the request does not contain the token.
+	ErrCodeTokenMissing = "ERR_CSRF_TOKEN_MISSING"
+	// ErrCodeTokenInvalid is the error code of the response when the token does not match.
+	ErrCodeTokenInvalid = "ERR_CSRF_TOKEN_INVALID"
+)
+
+var (
+	// ErrOriginMismatch is returned when Origin or Referer header is not allowed.
+	ErrOriginMismatch = errors.New("csrf: origin not allowed")
+	// ErrTokenMissing is returned when the request or the store does not contain the token.
+	ErrTokenMissing = errors.New("csrf: token missing")
+	// ErrTokenInvalid is returned when the token in the request does not match the stored token.
+	ErrTokenInvalid = errors.New("csrf: token invalid")
+)
+
+type ctxKeyToken struct{}
+
+// Options configures the CSRF middleware.
+type Options struct {
+	store          TokenStore
+	allowedOrigins []string
+	exemptPaths    []string
+	headerName     string
+	formField      string
+}
+
+// Option modifies Options.
+type Option func(*Options)
+
+// WithTokenStore sets the store of tokens. Default is DefaultCookieStore (double-submit cookie pattern).
+func WithTokenStore(s TokenStore) Option {
+	return func(o *Options) {
+		o.store = s
+	}
+}
+
+// WithAllowedOrigins sets origins (e.g. "https://app.example.com") allowed to send unsafe requests,
+// in addition to the origin of the request itself.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(o *Options) {
+		o.allowedOrigins = origins
+	}
+}
+
+// WithExemptPaths sets paths that are not protected (e.g. webhooks authenticated by a signature).
+// Patterns are matched with path.Match, so "/webhooks/*" is also supported.
+func WithExemptPaths(patterns ...string) Option {
+	return func(o *Options) {
+		o.exemptPaths = patterns
+	}
+}
+
+// WithHeaderName sets the request header containing the token. Default is X-CSRF-Token.
+func WithHeaderName(name string) Option {
+	return func(o *Options) {
+		o.headerName = name
+	}
+}
+
+// WithFormField sets the form field containing the token, checked if the header is missing. Default is csrf_token.
+func WithFormField(name string) Option {
+	return func(o *Options) {
+		o.formField = name
+	}
+}
+
+// Middleware protects cookie-authenticated endpoints against cross-site request forgery.
+//
+// For every request, a token is generated and saved to the TokenStore if the client does not have one yet,
+// and it can be obtained by TokenFromCtx (e.g. to render it in a form).
+//
+// Requests with unsafe methods (other than GET, HEAD, OPTIONS and TRACE) to non-exempt paths
+// are rejected with 403 Forbidden and a distinct error code, if:
+//   - Origin (or Referer, if Origin is missing) header is present and it is not the origin (scheme and host)
+//     of the request nor any of the allowed origins (ErrCodeOriginMismatch),
+//   - the token is missing in the request header or form field, or in the store (ErrCodeTokenMissing),
+//   - the token does not match the stored token (ErrCodeTokenInvalid).
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := Options{
+		store:      DefaultCookieStore(),
+		headerName: "X-CSRF-Token",
+		formField:  "csrf_token",
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := o.store.Get(r)
+			if err != nil {
+				_ = httpx.WriteErrorResponse(w, http.StatusInternalServerError, httpx.WithError(err))
+				return
+			}
+			stored := token != ""
+			if !stored {
+				token = newToken()
+				if err = o.store.Save(w, r, token); err != nil {
+					_ = httpx.WriteErrorResponse(w, http.StatusInternalServerError, httpx.WithError(err))
+					return
+				}
+			}
+			r = r.WithContext(context.WithValue(r.Context(), ctxKeyToken{}, token))
+
+			if isSafeMethod(r.Method) || o.isExempt(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !o.originAllowed(r) {
+				writeForbidden(w, ErrOriginMismatch, ErrCodeOriginMismatch)
+				return
+			}
+			submitted := r.Header.Get(o.headerName)
+			if submitted == "" && o.formField != "" {
+				submitted = r.PostFormValue(o.formField)
+			}
+			if submitted == "" || !stored {
+				writeForbidden(w, ErrTokenMissing, ErrCodeTokenMissing)
+				return
+			}
+			if subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+				writeForbidden(w, ErrTokenInvalid, ErrCodeTokenInvalid)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TokenFromCtx extracts the CSRF token of the client from the context.
+func TokenFromCtx(ctx context.Context) string {
+	token, ok := ctx.Value(ctxKeyToken{}).(string)
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+func (o Options) isExempt(p string) bool {
+	for _, pattern := range o.exemptPaths {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// originAllowed checks Origin header, or Referer header if Origin is missing.
+// Requests without both headers are allowed, as they are not sent by browsers in a cross-site context
+// and the token check is still applied. The origin of the request consists of both scheme and host,
+// so an http origin is not allowed for a request received over TLS.
+func (o Options) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		referer := r.Header.Get("Referer")
+		if referer == "" {
+			return origin == ""
+		}
+		u, err := url.Parse(referer)
+		if err != nil {
+			return false
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	scheme, host := requestOrigin(r)
+	if strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, host) {
+		return true
+	}
+	return slices.ContainsFunc(o.allowedOrigins, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
+
+// requestOrigin returns the scheme and the host of the request as seen by the client.
+// The client resolved by httpx.RealIPMiddleware is preferred, as it respects trusted forwarding headers.
+func requestOrigin(r *http.Request) (string, string) {
+	if client, ok := net.ClientFromCtx(r.Context()); ok && client.Scheme != "" && client.Host != "" {
+		return client.Scheme, client.Host
+	}
+	if r.TLS != nil {
+		return "https", r.Host
+	}
+	return "http", r.Host
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func newToken() string {
+	b := make([]byte, tokenSize)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeForbidden(w http.ResponseWriter, err error, code string) {
+	_ = httpx.WriteErrorResponse(w, http.StatusForbidden, httpx.WithError(err), httpx.WithErrorCode(code))
+}
@@ -0,0 +1,202 @@
+package csrf_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+	"go.strv.io/net/http/csrf"
+)
+
+const token = "secret-token"
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		client     *net.Client
+		form       url.Values
+		cookie     string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "success:safe-method",
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "success:header-token",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"X-CSRF-Token": token, "Origin": "https://app.example.com"},
+			cookie:     token,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "success:form-token-same-origin-referer",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"Referer": "https://api.example.com/form"},
+			form:       url.Values{"csrf_token": {token}},
+			cookie:     token,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "success:exempt-path",
+			method:     http.MethodPost,
+			path:       "/webhooks/stripe",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "failure:origin-mismatch",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"X-CSRF-Token": token, "Origin": "https://evil.com"},
+			cookie:     token,
+			wantStatus: http.StatusForbidden,
+			wantCode:   csrf.ErrCodeOriginMismatch,
+		},
+		{
+			name:       "failure:origin-scheme-mismatch",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"X-CSRF-Token": token, "Origin": "http://api.example.com"},
+			cookie:     token,
+			wantStatus: http.StatusForbidden,
+			wantCode:   csrf.ErrCodeOriginMismatch,
+		},
+		{
+			name:       "success:forwarded-origin",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"X-CSRF-Token": token, "Origin": "https://www.example.com"},
+			client:     &net.Client{Scheme: "https", Host: "www.example.com"},
+			cookie:     token,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "failure:forwarded-origin-scheme-mismatch",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"X-CSRF-Token": token, "Origin": "http://www.example.com"},
+			client:     &net.Client{Scheme: "https", Host: "www.example.com"},
+			cookie:     token,
+			wantStatus: http.StatusForbidden,
+			wantCode:   csrf.ErrCodeOriginMismatch,
+		},
+		{
+			name:       "failure:token-missing",
+			method:     http.MethodDelete,
+			path:       "/orders/1",
+			cookie:     token,
+			wantStatus: http.StatusForbidden,
+			wantCode:   csrf.ErrCodeTokenMissing,
+		},
+		{
+			name:       "failure:cookie-missing",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"X-CSRF-Token": token},
+			wantStatus: http.StatusForbidden,
+			wantCode:   csrf.ErrCodeTokenMissing,
+		},
+		{
+			name:       "failure:token-invalid",
+			method:     http.MethodPost,
+			path:       "/orders",
+			header:     map[string]string{"X-CSRF-Token": "forged"},
+			cookie:     token,
+			wantStatus: http.StatusForbidden,
+			wantCode:   csrf.ErrCodeTokenInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := csrf.Middleware(
+				csrf.WithAllowedOrigins("https://app.example.com"),
+				csrf.WithExemptPaths("/webhooks/*"),
+			)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			var body *strings.Reader
+			if tt.form != nil {
+				body = strings.NewReader(tt.form.Encode())
+			} else {
+				body = strings.NewReader("")
+			}
+			r := httptest.NewRequest(tt.method, "https://api.example.com"+tt.path, body)
+			if tt.form != nil {
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+			}
+			if tt.client != nil {
+				r = r.WithContext(net.WithClient(r.Context(), *tt.client))
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantCode != "" {
+				assert.JSONEq(t, `{"errorCode":"`+tt.wantCode+`"}`, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMiddleware_IssuesToken(t *testing.T) {
+	var ctxToken string
+	h := csrf.Middleware()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctxToken = csrf.TokenFromCtx(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "csrf_token", cookies[0].Name)
+	assert.NotEmpty(t, ctxToken)
+	assert.Equal(t, ctxToken, cookies[0].Value)
+}
+
+func TestMiddleware_SynchronizerToken(t *testing.T) {
+	session := map[string]string{}
+	store := csrf.SessionStoreFuncs{
+		GetFunc: func(*http.Request) (string, error) {
+			return session["csrf"], nil
+		},
+		SaveFunc: func(_ http.ResponseWriter, _ *http.Request, token string) error {
+			session["csrf"] = token
+			return nil
+		},
+	}
+	var formToken string
+	h := csrf.Middleware(csrf.WithTokenStore(store))(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		formToken = csrf.TokenFromCtx(r.Context())
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/form", nil))
+	require.NotEmpty(t, formToken)
+
+	r := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(url.Values{"csrf_token": {formToken}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,81 @@
+package csrf
+
+import (
+	"errors"
+	"net/http"
+)
+
+// TokenStore persists the CSRF token of a client.
+//
+// The store decides the protection pattern:
+//   - CookieStore implements the double-submit cookie pattern, the token is kept only by the client.
+//   - A store backed by a server-side session implements the synchronizer token pattern.
+type TokenStore interface {
+	// Get returns the token saved for the client of the request, or empty string if there is none.
+	Get(r *http.Request) (string, error)
+
+	// Save saves a new token for the client of the request.
+	Save(w http.ResponseWriter, r *http.Request, token string) error
+}
+
+// SessionStoreFuncs adapts functions reading and writing a session value to TokenStore,
+// e.g. for the synchronizer token pattern with an existing session library.
+type SessionStoreFuncs struct {
+	GetFunc  func(r *http.Request) (string, error)
+	SaveFunc func(w http.ResponseWriter, r *http.Request, token string) error
+}
+
+func (s SessionStoreFuncs) Get(r *http.Request) (string, error) {
+	return s.GetFunc(r)
+}
+
+func (s SessionStoreFuncs) Save(w http.ResponseWriter, r *http.Request, token string) error {
+	return s.SaveFunc(w, r, token)
+}
+
+// CookieStore is a TokenStore for the double-submit cookie pattern.
+// The cookie must be readable by the frontend (it is not HttpOnly), which sends it back in a header.
+type CookieStore struct {
+	Name     string
+	Path     string
+	Domain   string
+	MaxAge   int
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// DefaultCookieStore returns a CookieStore with a secure, site-wide cookie named csrf_token.
+func DefaultCookieStore() CookieStore {
+	return CookieStore{
+		Name:     "csrf_token",
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (c CookieStore) Get(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(c.Name)
+	if err != nil {
+		if errors.Is(err, http.ErrNoCookie) {
+			return "", nil
+		}
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+func (c CookieStore) Save(w http.ResponseWriter, _ *http.Request, token string) error {
+	//nolint:gosec // the cookie has to be readable by the frontend to be submitted back in a header
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.Name,
+		Value:    token,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   c.MaxAge,
+		Secure:   c.Secure,
+		HttpOnly: false,
+		SameSite: c.SameSite,
+	})
+	return nil
+}
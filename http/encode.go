@@ -3,23 +3,89 @@ package http
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io"
 	"net/http"
+
+	"gopkg.in/yaml.v3"
 )
 
 // EncodeFunc is a function that encodes data to the response writer.
 type EncodeFunc func(w http.ResponseWriter, data any) error
 
+// DecodeFunc is a function that decodes data read from the request body into v.
+type DecodeFunc func(r io.Reader, v any) error
+
 func EncodeJSON(w http.ResponseWriter, data any) error {
 	return json.NewEncoder(w).Encode(data)
 }
 
+// EncodeXML encodes data using encoding/xml, preceded by the XML header.
+// Note that encoding/xml does not support maps.
+func EncodeXML(w http.ResponseWriter, data any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(data)
+}
+
+// EncodeYAML encodes data using gopkg.in/yaml.v3. The data is marshaled as JSON first, so fields are named
+// by json tags (and json.Marshaler is used) the same way as in JSON responses, yaml tags are ignored.
+// Order of fields is kept.
+func EncodeYAML(w http.ResponseWriter, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML, the node keeps the order of fields.
+	var node yaml.Node
+	if err = yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	resetYAMLStyle(&node)
+	e := yaml.NewEncoder(w)
+	if err = e.Encode(&node); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+// resetYAMLStyle removes the JSON flow and quoting style of the parsed nodes, so they are encoded in block style.
+// Strings that would be read as another type (e.g. "true" or "1") are still quoted by the encoder.
+func resetYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		resetYAMLStyle(c)
+	}
+}
+
 func WithEncodeFunc(fn EncodeFunc) ResponseOption {
 	return func(o *ResponseOptions) {
 		o.EncodeFunc = fn
 	}
 }
 
+// DecodeJSONBody decodes JSON read from r into v.
+func DecodeJSONBody(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// DecodeXMLBody decodes XML read from r into v.
+func DecodeXMLBody(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// DecodeYAMLBody decodes YAML read from r into v. The document is converted to JSON first (see DecodeJSON),
+// so fields are named by json tags the same way as by EncodeYAML.
+func DecodeYAMLBody(r io.Reader, v any) error {
+	var data any
+	if err := yaml.NewDecoder(r).Decode(&data); err != nil {
+		return err
+	}
+	return DecodeJSON(data, v)
+}
+
 // DecodeJSON decodes data using JSON marshaling into the type of parameter v.
 func DecodeJSON(data any, v any) error {
 	b, err := json.Marshal(data)
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"net/http"
+
+	"go.strv.io/net"
+)
+
+// ErrorPolicy adjusts the error response before it is written, e.g. hides messages of server errors.
+// It is called after all error response options are applied and the message is translated (see WithMessageCatalog),
+// so the policy sees the message that would be written.
+type ErrorPolicy func(r *http.Request, statusCode int, o *ErrorResponseOptions)
+
+// HideServerErrorMessages returns an ErrorPolicy removing the message, the data and the problem extensions
+// (see WithProblemExtension) of 5xx error responses, so internal details do not leak to clients, e.g. in production.
+func HideServerErrorMessages() ErrorPolicy {
+	return func(_ *http.Request, statusCode int, o *ErrorResponseOptions) {
+		if statusCode >= http.StatusInternalServerError {
+			o.ErrMessage = ""
+			o.ErrData = nil
+			o.problemDetails.Extensions = nil
+		}
+	}
+}
+
+// StatusErrorCodes returns an ErrorPolicy setting the error code by the status code of the response,
+// if no error code was set (e.g. http.StatusNotFound: "ERR_NOT_FOUND").
+func StatusErrorCodes(codes map[int]string) ErrorPolicy {
+	return func(_ *http.Request, statusCode int, o *ErrorResponseOptions) {
+		if code, ok := codes[statusCode]; ok && o.ErrCode == defaultErrCode {
+			o.ErrCode = code
+		}
+	}
+}
+
+type ErrorWriterOptions struct {
+	policies []ErrorPolicy
+	opts     []ErrorResponseOption
+}
+
+type ErrorWriterOption func(*ErrorWriterOptions)
+
+// WithErrorPolicy adds policies applied to every error response, in the given order.
+func WithErrorPolicy(p ...ErrorPolicy) ErrorWriterOption {
+	return func(o *ErrorWriterOptions) {
+		o.policies = append(o.policies, p...)
+	}
+}
+
+// WithErrorResponseOptions adds options applied to every error response before the options passed to the write,
+// e.g. WithProblemDetails.
+func WithErrorResponseOptions(opts ...ErrorResponseOption) ErrorWriterOption {
+	return func(o *ErrorWriterOptions) {
+		o.opts = append(o.opts, opts...)
+	}
+}
+
+// ErrorWriter writes error responses of requests, filling request-scoped values and applying error policies.
+type ErrorWriter struct {
+	policies []ErrorPolicy
+	opts     []ErrorResponseOption
+}
+
+// NewErrorWriter creates an ErrorWriter, e.g. hiding messages of server errors in production:
+//
+//	ew := httpx.NewErrorWriter(httpx.WithErrorPolicy(httpx.HideServerErrorMessages()))
+func NewErrorWriter(opts ...ErrorWriterOption) *ErrorWriter {
+	o := ErrorWriterOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &ErrorWriter{
+		policies: o.policies,
+		opts:     o.opts,
+	}
+}
+
+// Write writes the error response like WriteErrorResponse, with the request ID from the context of the request
+// (see net.RequestIDFromCtx), unless it is set by the options. Policies of the writer are applied last,
+// after the message is translated.
+func (e *ErrorWriter) Write(w http.ResponseWriter, r *http.Request, statusCode int, opts ...ErrorResponseOption) error {
+	allOpts := append([]ErrorResponseOption{WithRequestID(net.RequestIDFromCtx(r.Context()))}, e.opts...)
+	allOpts = append(allOpts, opts...)
+	allOpts = append(allOpts, func(o *ErrorResponseOptions) {
+		o.finalizers = append(o.finalizers, func(o *ErrorResponseOptions) {
+			for _, p := range e.policies {
+				p(r, statusCode, o)
+			}
+		})
+	})
+	return WriteErrorResponse(w, statusCode, allOpts...)
+}
+
+// WriteError writes the error response of err with the status code, code and public message
+// of the application error in its chain, see WriteAppErrorResponse.
+func (e *ErrorWriter) WriteError(w http.ResponseWriter, r *http.Request, err error, opts ...ErrorResponseOption) error {
+	return e.Write(w, r, ErrorStatusCode(err), append([]ErrorResponseOption{WithAppError(err)}, opts...)...)
+}
+
+// WriteRequestErrorResponse writes the error response like WriteErrorResponse, with the request ID from the context
+// of the request. It is a shorthand for ErrorWriter without policies.
+func WriteRequestErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, opts ...ErrorResponseOption) error {
+	return NewErrorWriter().Write(w, r, statusCode, opts...)
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"go.strv.io/net"
+	neterrors "go.strv.io/net/errors"
+	"go.strv.io/net/internal"
+)
+
+// wrappingWriter is a writer of another middleware wrapping ResponseWriter.
+type wrappingWriter struct {
+	http.ResponseWriter
+}
+
+func (w wrappingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestErrorWriter(t *testing.T) {
+	errDB := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		writerOpts []ErrorWriterOption
+		write      func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error
+		wantStatus int
+		wantBody   string
+		wantErr    error
+	}{
+		{
+			name: "success:request-id",
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.Write(w, r, http.StatusBadRequest, WithError(errDB), WithErrorMessage("invalid"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"requestId":"request-1","errorCode":"ERR_UNKNOWN","errorMessage":"invalid"}` + "\n",
+			wantErr:    errDB,
+		},
+		{
+			name: "success:request-id-overridden",
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.Write(w, r, http.StatusBadRequest, WithRequestID("request-2"))
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"requestId":"request-2","errorCode":"ERR_UNKNOWN"}` + "\n",
+		},
+		{
+			name:       "success:hide-server-error-messages",
+			writerOpts: []ErrorWriterOption{WithErrorPolicy(HideServerErrorMessages())},
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.Write(w, r, http.StatusInternalServerError, WithError(errDB), WithErrorMessage(errDB.Error()), WithErrorData("users"))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"requestId":"request-1","errorCode":"ERR_UNKNOWN"}` + "\n",
+			wantErr:    errDB,
+		},
+		{
+			name:       "success:hide-translated-server-error-messages",
+			writerOpts: []ErrorWriterOption{WithErrorPolicy(HideServerErrorMessages())},
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				catalog := Catalog{language.English: {"ERR_INTERNAL": "Database is down."}}
+				return ew.Write(w, r, http.StatusInternalServerError, WithErrorCode("ERR_INTERNAL"), WithMessageCatalog(catalog, language.English))
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"requestId":"request-1","errorCode":"ERR_INTERNAL"}` + "\n",
+		},
+		{
+			name:       "success:hide-server-error-problem-extensions",
+			writerOpts: []ErrorWriterOption{WithErrorPolicy(HideServerErrorMessages())},
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.Write(
+					w,
+					r,
+					http.StatusInternalServerError,
+					WithProblemDetails(),
+					WithErrorMessage(errDB.Error()),
+					WithErrorData("users"),
+					WithProblemExtension("query", "SELECT * FROM users"),
+				)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   `{"errorCode":"ERR_UNKNOWN","requestId":"request-1","status":500,"title":"Internal Server Error","type":"about:blank"}` + "\n",
+		},
+		{
+			name:       "success:client-error-messages-kept",
+			writerOpts: []ErrorWriterOption{WithErrorPolicy(HideServerErrorMessages())},
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.Write(w, r, http.StatusConflict, WithErrorMessage("already exists"))
+			},
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"requestId":"request-1","errorCode":"ERR_UNKNOWN","errorMessage":"already exists"}` + "\n",
+		},
+		{
+			name: "success:status-error-codes",
+			writerOpts: []ErrorWriterOption{WithErrorPolicy(StatusErrorCodes(map[int]string{
+				http.StatusNotFound:            "ERR_NOT_FOUND",
+				http.StatusInternalServerError: "ERR_INTERNAL",
+			}))},
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.Write(w, r, http.StatusNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"requestId":"request-1","errorCode":"ERR_NOT_FOUND"}` + "\n",
+		},
+		{
+			name:       "success:default-options",
+			writerOpts: []ErrorWriterOption{WithErrorResponseOptions(WithProblemDetails())},
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.Write(w, r, http.StatusNotFound, WithErrorCode("ERR_NOT_FOUND"))
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"errorCode":"ERR_NOT_FOUND","requestId":"request-1","status":404,"title":"Not Found","type":"about:blank"}` + "\n",
+		},
+		{
+			name:       "success:app-error",
+			writerOpts: []ErrorWriterOption{WithErrorPolicy(HideServerErrorMessages())},
+			write: func(ew *ErrorWriter, w http.ResponseWriter, r *http.Request) error {
+				return ew.WriteError(w, r, neterrors.New(neterrors.KindUnavailable, "", "database is down", neterrors.WithCause(errDB)))
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"requestId":"request-1","errorCode":"ERR_UNAVAILABLE"}` + "\n",
+			wantErr:    errDB,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			r = r.WithContext(net.WithRequestID(r.Context(), "request-1"))
+			rec := httptest.NewRecorder()
+			rw := NewResponseWriter(rec, internal.NewNopLogger())
+
+			require.NoError(t, tt.write(NewErrorWriter(tt.writerOpts...), wrappingWriter{rw}, r))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, rw.ErrorObject(), tt.wantErr)
+			} else {
+				assert.NoError(t, rw.ErrorObject())
+			}
+		})
+	}
+}
+
+func TestWriteRequestErrorResponse(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r = r.WithContext(net.WithRequestID(r.Context(), "request-1"))
+	w := httptest.NewRecorder()
+
+	require.NoError(t, WriteRequestErrorResponse(w, r, http.StatusNotFound, WithErrorCode("ERR_NOT_FOUND")))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, `{"requestId":"request-1","errorCode":"ERR_NOT_FOUND"}`+"\n", w.Body.String())
+}
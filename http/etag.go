@@ -0,0 +1,299 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotModified is returned by CheckPreconditions when the client already has the current representation.
+	ErrNotModified = errors.New("not modified")
+	// ErrPreconditionFailed is returned by CheckPreconditions when If-Match or If-Unmodified-Since condition fails.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+const errCodePreconditionFailed = "ERR_PRECONDITION_FAILED"
+
+// ETagger is implemented by response types that know their entity tag (e.g. from a version column).
+// ETag returns either a complete entity tag (`"v1"` or `W/"v1"`), or an opaque value that is used as a strong tag.
+type ETagger interface {
+	ETag() string
+}
+
+// LastModifier is implemented by response types that know the time of their last modification.
+type LastModifier interface {
+	LastModified() time.Time
+}
+
+// Validators returns the entity tag and the last modification time of v,
+// if it implements ETagger or LastModifier respectively.
+func Validators(v any) (string, time.Time) {
+	var (
+		etag         string
+		lastModified time.Time
+	)
+	if e, ok := v.(ETagger); ok {
+		etag = formatETag(e.ETag())
+	}
+	if m, ok := v.(LastModifier); ok {
+		lastModified = m.LastModified()
+	}
+	return etag, lastModified
+}
+
+// SetValidators sets ETag and Last-Modified headers, empty values are skipped.
+func SetValidators(h http.Header, etag string, lastModified time.Time) {
+	if etag != "" {
+		h.Set(Header.ETag, etag)
+	}
+	if !lastModified.IsZero() {
+		h.Set(Header.LastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// ComputeETag returns an entity tag derived from the SHA-256 hash of data.
+func ComputeETag(data []byte, weak bool) string {
+	sum := sha256.Sum256(data)
+	//nolint:mnd // 16 bytes of the hash are unique enough
+	tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+	return tag
+}
+
+// CheckPreconditions evaluates conditional headers of the request against the current validators
+// of the resource, in the order defined by RFC 9110, section 13.2.2.
+//
+// It returns ErrPreconditionFailed if If-Match or If-Unmodified-Since fails, or if If-None-Match matches
+// on other methods than GET and HEAD. It returns ErrNotModified if If-None-Match or If-Modified-Since
+// indicates that the client already has the current representation. Otherwise, it returns nil.
+func CheckPreconditions(r *http.Request, etag string, lastModified time.Time) error {
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if ifMatch := r.Header.Get(Header.IfMatch); ifMatch != "" {
+		if !matchETag(ifMatch, etag, true) {
+			return ErrPreconditionFailed
+		}
+	} else if ius := r.Header.Get(Header.IfUnmodifiedSince); ius != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && lastModified.Truncate(time.Second).After(t) {
+			return ErrPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get(Header.IfNoneMatch); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, false) {
+			if safe {
+				return ErrNotModified
+			}
+			return ErrPreconditionFailed
+		}
+	} else if ims := r.Header.Get(Header.IfModifiedSince); ims != "" && safe && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.Truncate(time.Second).After(t) {
+			return ErrNotModified
+		}
+	}
+
+	return nil
+}
+
+// WritePreconditionResponse writes a response for an error returned by CheckPreconditions.
+// For ErrNotModified, 304 Not Modified without body is written,
+// for ErrPreconditionFailed, 412 Precondition Failed error response is written.
+func WritePreconditionResponse(w http.ResponseWriter, err error) error {
+	if errors.Is(err, ErrNotModified) {
+		w.Header().Del(Header.ContentType)
+		w.Header().Del(Header.ContentLength)
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return WriteErrorResponse(
+		w,
+		http.StatusPreconditionFailed,
+		WithError(err),
+		WithErrorCode(errCodePreconditionFailed),
+	)
+}
+
+// CurrentETagFunc returns the entity tag of the current state of the resource addressed by the request.
+type CurrentETagFunc func(r *http.Request) (string, error)
+
+type ETagMiddlewareOptions struct {
+	weak        bool
+	currentETag CurrentETagFunc
+}
+
+type ETagMiddlewareOption func(*ETagMiddlewareOptions)
+
+// WithWeakETag makes ETagMiddleware compute weak entity tags.
+func WithWeakETag() ETagMiddlewareOption {
+	return func(o *ETagMiddlewareOptions) {
+		o.weak = true
+	}
+}
+
+// WithCurrentETag makes ETagMiddleware enforce If-Match on PUT, PATCH and DELETE requests.
+// The function is called only if the request contains If-Match or If-None-Match header.
+func WithCurrentETag(f CurrentETagFunc) ETagMiddlewareOption {
+	return func(o *ETagMiddlewareOptions) {
+		o.currentETag = f
+	}
+}
+
+// ETagMiddleware adds entity tags to responses and handles conditional requests.
+//
+// For GET and HEAD requests, the response body is buffered. If the status code is 200 OK and the handler
+// did not set ETag header itself (e.g. by returning ETagger from signature handler), an entity tag is computed
+// from the body. Then the request preconditions are evaluated, and 304 Not Modified or 412 Precondition Failed
+// is written instead of the buffered response if needed. Streamed responses are not buffered: once the handler
+// flushes the response (e.g. StreamSeq, Server-Sent Events), the buffered part is written and the rest of the body
+// is written directly, without an entity tag.
+//
+// For PUT, PATCH and DELETE requests with WithCurrentETag option, the preconditions are evaluated against
+// the current entity tag before the handler is called, so the handler is not called at all
+// if the client modifies an outdated representation (optimistic concurrency).
+func ETagMiddleware(opts ...ETagMiddlewareOption) func(http.Handler) http.Handler {
+	options := ETagMiddlewareOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead:
+				serveWithETag(w, r, next, options.weak)
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				if options.currentETag == nil ||
+					(r.Header.Get(Header.IfMatch) == "" && r.Header.Get(Header.IfNoneMatch) == "") {
+					next.ServeHTTP(w, r)
+					return
+				}
+				etag, err := options.currentETag(r)
+				if err != nil {
+					_ = WriteErrorResponse(w, http.StatusInternalServerError, WithError(err))
+					return
+				}
+				if err = CheckPreconditions(r, etag, time.Time{}); err != nil {
+					_ = WritePreconditionResponse(w, err)
+					return
+				}
+				next.ServeHTTP(w, r)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func serveWithETag(w http.ResponseWriter, r *http.Request, next http.Handler, weak bool) {
+	bw := &bufferedResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	next.ServeHTTP(bw, r)
+
+	if bw.passthrough {
+		return
+	}
+	if bw.statusCode != http.StatusOK {
+		bw.flush()
+		return
+	}
+
+	etag := w.Header().Get(Header.ETag)
+	if etag == "" {
+		etag = ComputeETag(bw.body.Bytes(), weak)
+		w.Header().Set(Header.ETag, etag)
+	}
+	var lastModified time.Time
+	if lm := w.Header().Get(Header.LastModified); lm != "" {
+		lastModified, _ = http.ParseTime(lm)
+	}
+	if err := CheckPreconditions(r, etag, lastModified); err != nil {
+		_ = WritePreconditionResponse(w, err)
+		return
+	}
+	bw.flush()
+}
+
+// matchETag reports whether the entity tag matches any tag in the If-Match or If-None-Match header value.
+// Weak tags never match in the strong comparison.
+func matchETag(header string, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong {
+			if candidate == etag && !strings.HasPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func formatETag(tag string) string {
+	if tag == "" || strings.HasPrefix(tag, `"`) || strings.HasPrefix(tag, `W/"`) {
+		return tag
+	}
+	return `"` + tag + `"`
+}
+
+// bufferedResponseWriter holds the status code and the body until flush is called.
+// Headers are written directly to the underlying writer. When the handler flushes the response,
+// the writer stops buffering and passes writes through.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	passthrough bool
+	body        bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if b.wroteHeader {
+		return
+	}
+	b.wroteHeader = true
+	b.statusCode = statusCode
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.passthrough {
+		return b.ResponseWriter.Write(p)
+	}
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// Flush writes the buffered response and flushes the underlying writer, the following writes are not buffered.
+func (b *bufferedResponseWriter) Flush() {
+	if !b.passthrough {
+		b.passthrough = true
+		b.wroteHeader = true
+		b.flush()
+		b.body.Reset()
+	}
+	_ = http.NewResponseController(b.ResponseWriter).Flush()
+}
+
+func (b *bufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+func (b *bufferedResponseWriter) flush() {
+	b.ResponseWriter.WriteHeader(b.statusCode)
+	if b.body.Len() > 0 {
+		_, _ = b.ResponseWriter.Write(b.body.Bytes())
+	}
+}
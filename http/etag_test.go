@@ -0,0 +1,176 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	lastModified := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		method  string
+		header  map[string]string
+		etag    string
+		wantErr error
+	}{
+		{
+			name:   "success:no-conditions",
+			method: http.MethodGet,
+			etag:   `"v1"`,
+		},
+		{
+			name:    "success:if-none-match-not-modified",
+			method:  http.MethodGet,
+			header:  map[string]string{Header.IfNoneMatch: `"v0", W/"v1"`},
+			etag:    `"v1"`,
+			wantErr: ErrNotModified,
+		},
+		{
+			name:   "success:if-none-match-modified",
+			method: http.MethodGet,
+			header: map[string]string{Header.IfNoneMatch: `"v0"`},
+			etag:   `"v1"`,
+		},
+		{
+			name:    "success:if-modified-since-not-modified",
+			method:  http.MethodGet,
+			header:  map[string]string{Header.IfModifiedSince: lastModified.Format(http.TimeFormat)},
+			wantErr: ErrNotModified,
+		},
+		{
+			name:   "success:if-match",
+			method: http.MethodPut,
+			header: map[string]string{Header.IfMatch: `"v1"`},
+			etag:   `"v1"`,
+		},
+		{
+			name:    "failure:if-match-outdated",
+			method:  http.MethodPut,
+			header:  map[string]string{Header.IfMatch: `"v0"`},
+			etag:    `"v1"`,
+			wantErr: ErrPreconditionFailed,
+		},
+		{
+			name:    "failure:if-match-weak",
+			method:  http.MethodPut,
+			header:  map[string]string{Header.IfMatch: `W/"v1"`},
+			etag:    `W/"v1"`,
+			wantErr: ErrPreconditionFailed,
+		},
+		{
+			name:    "failure:if-none-match-any-on-put",
+			method:  http.MethodPut,
+			header:  map[string]string{Header.IfNoneMatch: "*"},
+			etag:    `"v1"`,
+			wantErr: ErrPreconditionFailed,
+		},
+		{
+			name:    "failure:if-unmodified-since",
+			method:  http.MethodDelete,
+			header:  map[string]string{Header.IfUnmodifiedSince: lastModified.Add(-time.Hour).Format(http.TimeFormat)},
+			wantErr: ErrPreconditionFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			err := CheckPreconditions(r, tt.etag, lastModified)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestETagMiddleware(t *testing.T) {
+	body := map[string]string{"name": "Testowic"}
+	etag := ComputeETag([]byte(`{"name":"Testowic"}`+"\n"), false)
+
+	handler := ETagMiddleware(
+		WithCurrentETag(func(*http.Request) (string, error) {
+			return etag, nil
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_ = WriteResponse(w, body, http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		header     map[string]string
+		wantStatus int
+		wantBody   bool
+	}{
+		{
+			name:       "success:computed-etag",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+		{
+			name:       "success:not-modified",
+			method:     http.MethodGet,
+			header:     map[string]string{Header.IfNoneMatch: etag},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "success:if-match",
+			method:     http.MethodPut,
+			header:     map[string]string{Header.IfMatch: etag},
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "failure:if-match-outdated",
+			method:     http.MethodPut,
+			header:     map[string]string{Header.IfMatch: `"outdated"`},
+			wantStatus: http.StatusPreconditionFailed,
+			wantBody:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/users/1", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.Len() > 0)
+			if tt.method == http.MethodGet {
+				assert.Equal(t, etag, rec.Header().Get(Header.ETag))
+			}
+		})
+	}
+}
+
+func TestETagMiddleware_Stream(t *testing.T) {
+	handler := ETagMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = StreamSeq(w, r, slices.Values([]int{1, 2}), WithFlushEvery(1))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, rec.Flushed)
+	assert.Empty(t, rec.Header().Get(Header.ETag))
+	assert.Equal(t, "1\n2\n", rec.Body.String())
+}
@@ -3,20 +3,74 @@ package http
 var (
 	// Header contains predefined headers.
 	Header = struct {
-		AcceptLanguage  string
-		Authorization   string
-		ContentLanguage string
-		ContentType     string
-		WWWAuthenticate string
-		XRequestID      string
-		AmazonTraceID   string
+		Accept                          string
+		AcceptLanguage                  string
+		Allow                           string
+		Authorization                   string
+		ContentLanguage                 string
+		ContentLength                   string
+		ContentSecurityPolicy           string
+		ContentSecurityPolicyReportOnly string
+		ContentType                     string
+		CrossOriginEmbedderPolicy       string
+		CrossOriginOpenerPolicy         string
+		ETag                            string
+		Forwarded                       string
+		IdempotencyKey                  string
+		IfMatch                         string
+		IfModifiedSince                 string
+		IfNoneMatch                     string
+		IfUnmodifiedSince               string
+		LastModified                    string
+		PermissionsPolicy               string
+		ReferrerPolicy                  string
+		StrictTransportSecurity         string
+		Traceparent                     string
+		Vary                            string
+		Tracestate                      string
+		WWWAuthenticate                 string
+		XContentTypeOptions             string
+		XForwardedFor                   string
+		XForwardedHost                  string
+		XForwardedProto                 string
+		XFrameOptions                   string
+		XRealIP                         string
+		XRequestID                      string
+		AmazonTraceID                   string
 	}{
-		AcceptLanguage:  "Accept-Language",
-		Authorization:   "Authorization",
-		ContentLanguage: "Content-Language",
-		ContentType:     "Content-Type",
-		WWWAuthenticate: "WWW-Authenticate",
-		XRequestID:      "X-Request-Id",
-		AmazonTraceID:   "X-Amzn-Trace-Id",
+		Accept:                          "Accept",
+		AcceptLanguage:                  "Accept-Language",
+		Allow:                           "Allow",
+		Authorization:                   "Authorization",
+		ContentLanguage:                 "Content-Language",
+		ContentLength:                   "Content-Length",
+		ContentSecurityPolicy:           "Content-Security-Policy",
+		ContentSecurityPolicyReportOnly: "Content-Security-Policy-Report-Only",
+		ContentType:                     "Content-Type",
+		CrossOriginEmbedderPolicy:       "Cross-Origin-Embedder-Policy",
+		CrossOriginOpenerPolicy:         "Cross-Origin-Opener-Policy",
+		ETag:                            "ETag",
+		Forwarded:                       "Forwarded",
+		IdempotencyKey:                  "Idempotency-Key",
+		IfMatch:                         "If-Match",
+		IfModifiedSince:                 "If-Modified-Since",
+		IfNoneMatch:                     "If-None-Match",
+		IfUnmodifiedSince:               "If-Unmodified-Since",
+		LastModified:                    "Last-Modified",
+		PermissionsPolicy:               "Permissions-Policy",
+		ReferrerPolicy:                  "Referrer-Policy",
+		StrictTransportSecurity:         "Strict-Transport-Security",
+		Traceparent:                     "Traceparent",
+		Vary:                            "Vary",
+		Tracestate:                      "Tracestate",
+		WWWAuthenticate:                 "WWW-Authenticate",
+		XContentTypeOptions:             "X-Content-Type-Options",
+		XForwardedFor:                   "X-Forwarded-For",
+		XForwardedHost:                  "X-Forwarded-Host",
+		XForwardedProto:                 "X-Forwarded-Proto",
+		XFrameOptions:                   "X-Frame-Options",
+		XRealIP:                         "X-Real-Ip",
+		XRequestID:                      "X-Request-Id",
+		AmazonTraceID:                   "X-Amzn-Trace-Id",
 	}
 )
@@ -0,0 +1,277 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.strv.io/net"
+	httpx "go.strv.io/net/http"
+	"go.strv.io/net/internal"
+)
+
+const (
+	defaultTTL          = 24 * time.Hour
+	defaultLockTTL      = time.Minute
+	defaultMaxKeyLength = 255
+	defaultMaxBodySize  = 1 << 20
+
+	// ReplayedHeader is set to "true" on responses replayed from the Store.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	errCodeInvalidKey   = "ERR_INVALID_IDEMPOTENCY_KEY"
+	errCodeKeyMismatch  = "ERR_IDEMPOTENCY_KEY_MISMATCH"
+	errCodeInProgress   = "ERR_IDEMPOTENCY_KEY_IN_PROGRESS"
+	errCodeBodyTooLarge = "ERR_REQUEST_BODY_TOO_LARGE"
+)
+
+var (
+	// ErrInvalidKey is passed to the error response when the key is too long.
+	ErrInvalidKey = errors.New("invalid idempotency key")
+	// ErrKeyMismatch is passed to the error response when the key was already used with a different request.
+	ErrKeyMismatch = errors.New("idempotency key used with different request")
+	// ErrInProgress is passed to the error response when the request with the same key is still being processed.
+	ErrInProgress = errors.New("request with idempotency key in progress")
+	// ErrBodyTooLarge is passed to the error response when the request body exceeds the limit, see WithMaxBodySize.
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+// PrincipalFunc returns an identifier of the principal the idempotency key is scoped to.
+type PrincipalFunc func(r *http.Request) string
+
+// MiddlewareOptions configures the idempotency middleware.
+type MiddlewareOptions struct {
+	methods       []string
+	ttl           time.Duration
+	lockTTL       time.Duration
+	maxKeyLength  int
+	maxBodySize   int64
+	principalFunc PrincipalFunc
+	anonymous     bool
+	logger        *slog.Logger
+}
+
+// MiddlewareOption modifies MiddlewareOptions.
+type MiddlewareOption func(*MiddlewareOptions)
+
+// WithMethods sets HTTP methods the idempotency key is honored for. Default are POST and PATCH.
+func WithMethods(methods ...string) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.methods = methods
+	}
+}
+
+// WithTTL sets how long the completed response is stored. Default is 24 hours.
+func WithTTL(ttl time.Duration) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.ttl = ttl
+	}
+}
+
+// WithLockTTL sets how long the key stays locked if the request never completes (e.g. the instance crashed).
+// It should be longer than the longest expected request duration. Default is 1 minute.
+func WithLockTTL(ttl time.Duration) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.lockTTL = ttl
+	}
+}
+
+// WithMaxBodySize limits the number of bytes read from the request body for the fingerprint. Default is 1 MiB.
+func WithMaxBodySize(n int64) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.maxBodySize = n
+	}
+}
+
+// WithPrincipalFunc sets a function returning the principal the key is scoped to.
+// By default, net.PrincipalFromCtx is used, so the authentication middleware has to run before this middleware.
+func WithPrincipalFunc(f PrincipalFunc) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.principalFunc = f
+	}
+}
+
+// WithAnonymous makes the middleware honor the key also for requests without a principal.
+// All anonymous clients share one namespace of keys, so a client can get the response stored for another client
+// by guessing its key. Use it only if the keys are unguessable (e.g. random UUIDs) and the responses are not sensitive.
+func WithAnonymous() MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.anonymous = true
+	}
+}
+
+// WithLogger sets a logger for the response writer created by the middleware.
+func WithLogger(l *slog.Logger) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.logger = l
+	}
+}
+
+// Middleware honors the Idempotency-Key header for safe retries of non-idempotent requests.
+//
+// The first request with a key is processed and its response (status code, headers and body) is stored in the Store.
+// Subsequent requests with the same key and the same principal get the stored response replayed,
+// with the Idempotent-Replayed header set. Requests without the header are passed through, as well as requests
+// without a principal, unless WithAnonymous is used.
+//
+// The key is locked while the first request is processed, concurrent duplicates are rejected with 409 Conflict.
+// If the same key is used with a different request (method, path or body), the request is rejected
+// with 422 Unprocessable Entity. Responses with status code >= 500 are not stored, so the request can be retried.
+// Requests with body larger than the limit (see WithMaxBodySize) are rejected with 413 Content Too Large.
+func Middleware(store Store, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := MiddlewareOptions{
+		methods:      []string{http.MethodPost, http.MethodPatch},
+		ttl:          defaultTTL,
+		lockTTL:      defaultLockTTL,
+		maxKeyLength: defaultMaxKeyLength,
+		maxBodySize:  defaultMaxBodySize,
+		principalFunc: func(r *http.Request) string {
+			return net.PrincipalFromCtx(r.Context())
+		},
+		logger: internal.NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(httpx.Header.IdempotencyKey)
+			if key == "" || !slices.Contains(o.methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			principal := o.principalFunc(r)
+			if principal == "" && !o.anonymous {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > o.maxKeyLength {
+				writeError(w, http.StatusBadRequest, ErrInvalidKey, errCodeInvalidKey)
+				return
+			}
+
+			fingerprint, err := fingerprintRequest(r, o.maxBodySize)
+			if errors.Is(err, ErrBodyTooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, err, errCodeBodyTooLarge)
+				return
+			}
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err, errCodeInvalidKey)
+				return
+			}
+
+			storeKey := principal + ":" + key
+			record, err := store.Lock(r.Context(), storeKey, fingerprint, o.lockTTL)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, fmt.Errorf("locking idempotency key: %w", err), "")
+				return
+			}
+			switch {
+			case record == nil:
+				serveAndSave(w, r, next, store, storeKey, fingerprint, o)
+			case record.Fingerprint != fingerprint:
+				writeError(w, http.StatusUnprocessableEntity, ErrKeyMismatch, errCodeKeyMismatch)
+			case !record.Completed:
+				writeError(w, http.StatusConflict, ErrInProgress, errCodeInProgress)
+			default:
+				replay(w, record)
+			}
+		})
+	}
+}
+
+func serveAndSave(
+	w http.ResponseWriter,
+	r *http.Request,
+	next http.Handler,
+	store Store,
+	key string,
+	fingerprint string,
+	o MiddlewareOptions,
+) {
+	// Context of the request may be canceled by now, but the store must be updated anyway.
+	ctx := context.WithoutCancel(r.Context())
+
+	rw, ok := w.(*httpx.ResponseWriter)
+	if !ok {
+		rw = httpx.NewResponseWriter(w, o.logger)
+	}
+	body := &bytes.Buffer{}
+	rw.TeeBody(body)
+
+	saved := false
+	defer func() {
+		// Handler failed or panicked, let the client retry.
+		if !saved {
+			if err := store.Unlock(ctx, key); err != nil {
+				o.logger.ErrorContext(ctx, "unlocking idempotency key", slog.Any("error", err))
+			}
+		}
+	}()
+
+	next.ServeHTTP(rw, r)
+
+	if rw.StatusCode() >= http.StatusInternalServerError || rw.PanicObject() != nil {
+		return
+	}
+	header := rw.Header().Clone()
+	header.Del(httpx.Header.XRequestID)
+	record := Record{
+		Fingerprint: fingerprint,
+		Completed:   true,
+		StatusCode:  rw.StatusCode(),
+		Header:      header,
+		Body:        body.Bytes(),
+	}
+	if err := store.Save(ctx, key, record, o.ttl); err != nil {
+		o.logger.ErrorContext(ctx, "saving idempotent response", slog.Any("error", err))
+		return
+	}
+	saved = true
+}
+
+func replay(w http.ResponseWriter, record *Record) {
+	for k, v := range record.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
+
+// fingerprintRequest hashes method, path and body of the request. The body is replaced with an in-memory copy.
+func fingerprintRequest(r *http.Request, limit int64) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.EscapedPath() + "\n"))
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+		_ = r.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("reading body: %w", err)
+		}
+		if int64(len(body)) > limit {
+			return "", fmt.Errorf("%w: exceeds %d bytes", ErrBodyTooLarge, limit)
+		}
+		h.Write(body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func writeError(w http.ResponseWriter, statusCode int, err error, code string) {
+	opts := []httpx.ErrorResponseOption{httpx.WithError(err)}
+	if code != "" {
+		opts = append(opts, httpx.WithErrorCode(code))
+	}
+	_ = httpx.WriteErrorResponse(w, statusCode, opts...)
+}
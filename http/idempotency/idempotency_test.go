@@ -0,0 +1,210 @@
+package idempotency_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+	"go.strv.io/net/http/idempotency"
+)
+
+type call struct {
+	principal string
+	key       string
+	body      string
+}
+
+func TestMiddleware(t *testing.T) {
+	type response struct {
+		code     int
+		body     string
+		replayed bool
+	}
+	tests := []struct {
+		name     string
+		opts     []idempotency.MiddlewareOption
+		calls    []call
+		want     []response
+		handlers int
+	}{
+		{
+			name: "success:replay",
+			calls: []call{
+				{principal: "user-1", key: "key-1", body: `{"amount":10}`},
+				{principal: "user-1", key: "key-1", body: `{"amount":10}`},
+			},
+			want: []response{
+				{code: http.StatusCreated, body: `{"id":1}`},
+				{code: http.StatusCreated, body: `{"id":1}`, replayed: true},
+			},
+			handlers: 1,
+		},
+		{
+			name: "success:different-principal",
+			calls: []call{
+				{principal: "user-1", key: "key-1", body: `{"amount":10}`},
+				{principal: "user-2", key: "key-1", body: `{"amount":10}`},
+			},
+			want: []response{
+				{code: http.StatusCreated, body: `{"id":1}`},
+				{code: http.StatusCreated, body: `{"id":2}`},
+			},
+			handlers: 2,
+		},
+		{
+			name: "success:without-key",
+			calls: []call{
+				{principal: "user-1", body: `{"amount":10}`},
+				{principal: "user-1", body: `{"amount":10}`},
+			},
+			want: []response{
+				{code: http.StatusCreated, body: `{"id":1}`},
+				{code: http.StatusCreated, body: `{"id":2}`},
+			},
+			handlers: 2,
+		},
+		{
+			name: "success:anonymous-not-replayed",
+			calls: []call{
+				{key: "key-1", body: `{"amount":10}`},
+				{key: "key-1", body: `{"amount":10}`},
+			},
+			want: []response{
+				{code: http.StatusCreated, body: `{"id":1}`},
+				{code: http.StatusCreated, body: `{"id":2}`},
+			},
+			handlers: 2,
+		},
+		{
+			name: "success:anonymous-allowed",
+			opts: []idempotency.MiddlewareOption{idempotency.WithAnonymous()},
+			calls: []call{
+				{key: "key-1", body: `{"amount":10}`},
+				{key: "key-1", body: `{"amount":10}`},
+			},
+			want: []response{
+				{code: http.StatusCreated, body: `{"id":1}`},
+				{code: http.StatusCreated, body: `{"id":1}`, replayed: true},
+			},
+			handlers: 1,
+		},
+		{
+			name: "failure:key-mismatch",
+			calls: []call{
+				{principal: "user-1", key: "key-1", body: `{"amount":10}`},
+				{principal: "user-1", key: "key-1", body: `{"amount":20}`},
+			},
+			want: []response{
+				{code: http.StatusCreated, body: `{"id":1}`},
+				{code: http.StatusUnprocessableEntity, body: `{"errorCode":"ERR_IDEMPOTENCY_KEY_MISMATCH"}`},
+			},
+			handlers: 1,
+		},
+		{
+			name: "failure:body-too-large",
+			opts: []idempotency.MiddlewareOption{idempotency.WithMaxBodySize(4)},
+			calls: []call{
+				{principal: "user-1", key: "key-1", body: `{"amount":10}`},
+			},
+			want: []response{
+				{code: http.StatusRequestEntityTooLarge, body: `{"errorCode":"ERR_REQUEST_BODY_TOO_LARGE"}`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers := 0
+			h := idempotency.Middleware(idempotency.NewMemoryStore(), tt.opts...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, err := io.ReadAll(r.Body)
+					require.NoError(t, err)
+					handlers++
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"id":` + strconv.Itoa(handlers) + `}`))
+				}),
+			)
+
+			for i, c := range tt.calls {
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, newRequest(c))
+
+				assert.Equal(t, tt.want[i].code, rec.Code)
+				assert.JSONEq(t, tt.want[i].body, rec.Body.String())
+				if tt.want[i].replayed {
+					assert.Equal(t, "true", rec.Header().Get(idempotency.ReplayedHeader))
+				} else {
+					assert.Empty(t, rec.Header().Get(idempotency.ReplayedHeader))
+				}
+			}
+			assert.Equal(t, tt.handlers, handlers)
+		})
+	}
+}
+
+func TestMiddleware_InProgress(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	c := call{principal: "user-1", key: "key-1", body: `{"amount":10}`}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	h := idempotency.Middleware(store)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), newRequest(c))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(c))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	close(finish)
+	<-done
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(idempotency.ReplayedHeader))
+}
+
+func TestMiddleware_ServerErrorNotStored(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	c := call{principal: "user-1", key: "key-1", body: `{"amount":10}`}
+
+	handlers := 0
+	h := idempotency.Middleware(store)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		handlers++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), newRequest(c))
+	h.ServeHTTP(httptest.NewRecorder(), newRequest(c))
+	assert.Equal(t, 2, handlers)
+
+	record, err := store.Lock(context.Background(), "user-1:key-1", "", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func newRequest(c call) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(c.body))
+	if c.key != "" {
+		r.Header.Set("Idempotency-Key", c.key)
+	}
+	return r.WithContext(net.WithPrincipal(r.Context(), c.principal))
+}
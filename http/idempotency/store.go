@@ -0,0 +1,105 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Record is a state of a request with an idempotency key.
+type Record struct {
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string
+
+	// Completed is false while the first request is still being processed.
+	Completed bool
+
+	// StatusCode, Header and Body are the stored response of the first request. Set only if Completed.
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Store persists idempotency records.
+// Implementations must be safe for concurrent use, and Lock must be atomic across all instances of a service
+// sharing the store.
+type Store interface {
+	// Lock creates an uncompleted record for the key if none exists and returns nil.
+	// If a record already exists (completed or not), it is returned and nothing is changed.
+	// The lock expires after ttl, so a crashed request does not block the key forever.
+	Lock(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error)
+
+	// Save replaces the uncompleted record with a completed one, which expires after ttl.
+	Save(ctx context.Context, key string, record Record, ttl time.Duration) error
+
+	// Unlock removes the uncompleted record, so the request can be retried.
+	Unlock(ctx context.Context, key string) error
+}
+
+// MemoryStore is an in-memory Store.
+// It is suitable only for a single instance of a service, use a shared store (e.g. Redis) otherwise.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]memoryRecord
+	now     func() time.Time
+}
+
+type memoryRecord struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: map[string]memoryRecord{},
+		now:     time.Now,
+	}
+}
+
+// Lock implements Store. Expired records are removed on each call.
+func (s *MemoryStore) Lock(_ context.Context, key string, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, r := range s.records {
+		if !now.Before(r.expiresAt) {
+			delete(s.records, k)
+		}
+	}
+
+	if r, ok := s.records[key]; ok {
+		record := r.record
+		return &record, nil
+	}
+	s.records[key] = memoryRecord{
+		record:    Record{Fingerprint: fingerprint},
+		expiresAt: now.Add(ttl),
+	}
+	return nil, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(_ context.Context, key string, record Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = memoryRecord{
+		record:    record,
+		expiresAt: s.now().Add(ttl),
+	}
+	return nil
+}
+
+// Unlock implements Store.
+func (s *MemoryStore) Unlock(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.records[key]; ok && !r.record.Completed {
+		delete(s.records, key)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"net/http"
+
+	"golang.org/x/text/language"
+
+	"go.strv.io/net"
+)
+
+// LanguageMiddleware negotiates the language of the response by matching Accept-Language header against
+// the supported languages. The first supported language is the default, used if nothing matches.
+// The negotiated language is saved into the context (see net.LanguageFromCtx) and set as Content-Language header.
+// Vary header is extended by Accept-Language, so caches store responses in different languages separately.
+func LanguageMiddleware(supported ...language.Tag) func(http.Handler) http.Handler {
+	if len(supported) == 0 {
+		supported = []language.Tag{language.English}
+	}
+	matcher := language.NewMatcher(supported)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Invalid entries of the header are skipped, an empty list results in the default language.
+			tags, _, _ := language.ParseAcceptLanguage(r.Header.Get(Header.AcceptLanguage))
+			_, index, _ := matcher.Match(tags...)
+			tag := supported[index]
+
+			w.Header().Set(Header.ContentLanguage, tag.String())
+			w.Header().Add(Header.Vary, Header.AcceptLanguage)
+
+			ctx := net.WithLanguage(r.Context(), tag)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// MessageCatalog provides error messages in multiple languages.
+type MessageCatalog interface {
+	// Message returns the message of the error code in the language, false if there is no such message.
+	Message(tag language.Tag, code string) (string, bool)
+}
+
+// Catalog is a MessageCatalog mapping languages to messages keyed by error codes, e.g.:
+//
+//	httpx.Catalog{
+//		language.English: {"ERR_NOT_FOUND": "Resource not found."},
+//		language.Czech:   {"ERR_NOT_FOUND": "Zdroj nebyl nalezen."},
+//	}
+//
+// If there is no message in the language, parent languages are tried, e.g. en-GB falls back to en.
+type Catalog map[language.Tag]map[string]string
+
+func (c Catalog) Message(tag language.Tag, code string) (string, bool) {
+	for {
+		if msg, ok := c[tag][code]; ok {
+			return msg, true
+		}
+		if tag.IsRoot() {
+			return "", false
+		}
+		tag = tag.Parent()
+	}
+}
+
+// WithMessageCatalog translates the error message by the error code to the language using the catalog.
+// The language is usually negotiated by LanguageMiddleware and taken from the context by net.LanguageFromCtx.
+// If the catalog does not contain the message, the message set by WithErrorMessage is kept.
+func WithMessageCatalog(c MessageCatalog, tag language.Tag) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.catalog = c
+		o.language = tag
+	}
+}
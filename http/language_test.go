@@ -0,0 +1,126 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+
+	"go.strv.io/net"
+)
+
+func TestLanguageMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           language.Tag
+	}{
+		{
+			name: "success:default",
+			want: language.English,
+		},
+		{
+			name:           "success:exact",
+			acceptLanguage: "cs",
+			want:           language.Czech,
+		},
+		{
+			name:           "success:q-values",
+			acceptLanguage: "de;q=0.5, cs;q=0.8, fr;q=0.1",
+			want:           language.Czech,
+		},
+		{
+			name:           "success:region",
+			acceptLanguage: "de-AT",
+			want:           language.German,
+		},
+		{
+			name:           "success:unsupported",
+			acceptLanguage: "ja",
+			want:           language.English,
+		},
+		{
+			name:           "success:invalid",
+			acceptLanguage: "!!!",
+			want:           language.English,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got language.Tag
+			h := LanguageMiddleware(language.English, language.Czech, language.German)(
+				http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					got, _ = net.LanguageFromCtx(r.Context())
+				}),
+			)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set(Header.AcceptLanguage, tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.String(), w.Header().Get(Header.ContentLanguage))
+			assert.Equal(t, Header.AcceptLanguage, w.Header().Get(Header.Vary))
+		})
+	}
+}
+
+func TestWithMessageCatalog(t *testing.T) {
+	catalog := Catalog{
+		language.English: {"ERR_NOT_FOUND": "Resource not found."},
+		language.Czech:   {"ERR_NOT_FOUND": "Zdroj nebyl nalezen."},
+	}
+	tests := []struct {
+		name        string
+		tag         language.Tag
+		code        string
+		wantMessage string
+	}{
+		{
+			name:        "success:translated",
+			tag:         language.Czech,
+			code:        "ERR_NOT_FOUND",
+			wantMessage: "Zdroj nebyl nalezen.",
+		},
+		{
+			name:        "success:parent-language",
+			tag:         language.BritishEnglish,
+			code:        "ERR_NOT_FOUND",
+			wantMessage: "Resource not found.",
+		},
+		{
+			name:        "success:missing-message-kept",
+			tag:         language.Czech,
+			code:        "ERR_CONFLICT",
+			wantMessage: "fallback",
+		},
+		{
+			name:        "success:missing-language-kept",
+			tag:         language.German,
+			code:        "ERR_NOT_FOUND",
+			wantMessage: "fallback",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, WriteErrorResponse(
+				w,
+				http.StatusNotFound,
+				WithErrorCode(tt.code),
+				WithErrorMessage("fallback"),
+				WithMessageCatalog(catalog, tt.tag),
+			))
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantMessage, body["errorMessage"])
+		})
+	}
+}
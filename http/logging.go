@@ -0,0 +1,212 @@
+package http
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"path"
+	"time"
+
+	"go.strv.io/net/internal"
+)
+
+// LogField is a set of optional fields logged by LoggingMiddleware.
+type LogField uint
+
+const (
+	// LogFieldUserAgent logs the User-Agent header as user_agent.
+	LogFieldUserAgent LogField = 1 << iota
+	// LogFieldClientIP logs client_ip even if it is not resolved by RealIPMiddleware, using RemoteAddr of the request.
+	LogFieldClientIP
+	// LogFieldQuery logs the raw query of the URL as query.
+	LogFieldQuery
+	// LogFieldRoute logs the route pattern matched by chi router as route.
+	// The middleware has to be registered with chi.Router.Use to see the matched route.
+	LogFieldRoute
+	// LogFieldBytesIn logs the size of the request body as bytes_in.
+	LogFieldBytesIn
+	// LogFieldBytesOut logs the size of the response body as bytes_out.
+	LogFieldBytesOut
+	// LogFieldReferer logs the Referer header as referer.
+	LogFieldReferer
+)
+
+func (f LogField) has(field LogField) bool {
+	return f&field != 0
+}
+
+// LevelFunc returns a level the request with the status code is logged with.
+type LevelFunc func(statusCode int) slog.Level
+
+// DefaultLevelFunc logs requests with status code >= 500 with error level, info otherwise.
+var DefaultLevelFunc = StatusLevels(slog.LevelInfo, slog.LevelInfo, slog.LevelError)
+
+// StatusLevels returns a LevelFunc logging successful requests (status code < 400), client errors (4xx)
+// and server errors (5xx) with the given levels.
+func StatusLevels(success, clientError, serverError slog.Level) LevelFunc {
+	return func(statusCode int) slog.Level {
+		switch {
+		case statusCode >= http.StatusInternalServerError:
+			return serverError
+		case statusCode >= http.StatusBadRequest:
+			return clientError
+		default:
+			return success
+		}
+	}
+}
+
+type LoggingMiddlewareOptions struct {
+	format        AccessLogFormat
+	fields        LogField
+	headers       []string
+	levelFunc     LevelFunc
+	slowThreshold time.Duration
+	skippedPaths  []string
+	sampledPaths  []string
+	sampleRate    float64
+}
+
+type LoggingMiddlewareOption func(*LoggingMiddlewareOptions)
+
+func newLoggingMiddlewareOptions(opts []LoggingMiddlewareOption) LoggingMiddlewareOptions {
+	options := LoggingMiddlewareOptions{
+		levelFunc: DefaultLevelFunc,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
+
+// WithAccessLogFormat makes LoggingMiddleware log attributes of the format (e.g. ECSFormat) instead of
+// the request group. Error, panic and attributes added by ResponseWriter.AddLogAttrs are still logged.
+func WithAccessLogFormat(f AccessLogFormat) LoggingMiddlewareOption {
+	return func(o *LoggingMiddlewareOptions) {
+		o.format = f
+	}
+}
+
+// WithLogFields enables optional fields, e.g. WithLogFields(LogFieldUserAgent, LogFieldRoute).
+func WithLogFields(fields ...LogField) LoggingMiddlewareOption {
+	return func(o *LoggingMiddlewareOptions) {
+		for _, f := range fields {
+			o.fields |= f
+		}
+	}
+}
+
+// WithLoggedHeaders sets request headers that are logged in the headers group. No headers are logged by default,
+// as they may contain credentials.
+func WithLoggedHeaders(names ...string) LoggingMiddlewareOption {
+	return func(o *LoggingMiddlewareOptions) {
+		o.headers = names
+	}
+}
+
+// WithLevelFunc sets a function mapping the status code to the level of the log. Default is DefaultLevelFunc,
+// StatusLevels can be used to change levels of client and server errors.
+func WithLevelFunc(f LevelFunc) LoggingMiddlewareOption {
+	return func(o *LoggingMiddlewareOptions) {
+		o.levelFunc = f
+	}
+}
+
+// WithSlowRequestThreshold raises the level of requests taking at least the threshold to warn
+// (if the level is lower).
+func WithSlowRequestThreshold(threshold time.Duration) LoggingMiddlewareOption {
+	return func(o *LoggingMiddlewareOptions) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithSkippedPaths disables logging of requests to the paths, e.g. "/healthz".
+// Patterns are matched with path.Match, so "/internal/*" is also supported.
+func WithSkippedPaths(patterns ...string) LoggingMiddlewareOption {
+	return func(o *LoggingMiddlewareOptions) {
+		o.skippedPaths = patterns
+	}
+}
+
+// WithSampledPaths logs only a fraction (0 to 1) of requests to the paths. Requests with a level above info
+// (e.g. failed or slow requests) are always logged. Patterns are matched with path.Match.
+func WithSampledPaths(rate float64, patterns ...string) LoggingMiddlewareOption {
+	return func(o *LoggingMiddlewareOptions) {
+		o.sampleRate = rate
+		o.sampledPaths = patterns
+	}
+}
+
+// requestLogMiddleware collects RequestData of each request not skipped nor sampled out, and passes it to emit
+// together with the level the request should be logged with.
+func requestLogMiddleware(
+	l *slog.Logger,
+	options LoggingMiddlewareOptions,
+	emit func(r *http.Request, rw *ResponseWriter, rd RequestData, level slog.Level),
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matchPath(options.skippedPaths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw, ok := w.(*ResponseWriter)
+			if !ok {
+				rw = NewResponseWriter(w, l)
+			}
+			var requestBody *internal.CountingReadCloser
+			if r.Body != nil && r.Body != http.NoBody {
+				requestBody = &internal.CountingReadCloser{ReadCloser: r.Body}
+				r.Body = requestBody
+			}
+
+			requestStart := time.Now()
+			next.ServeHTTP(rw, r)
+
+			rd := newRequestData(r, rw, requestStart, requestBody)
+			rd.Fields = options.fields
+			rd.Headers = filterHeaders(r.Header, options.headers)
+
+			level := options.levelFunc(rd.ResponseStatusCode)
+			if options.slowThreshold > 0 && rd.Duration >= options.slowThreshold && level < slog.LevelWarn {
+				level = slog.LevelWarn
+			}
+			if !options.sampled(r.URL.Path, level) {
+				return
+			}
+			emit(r, rw, rd, level)
+		})
+	}
+}
+
+// sampled reports whether the request to the path should be logged.
+func (o LoggingMiddlewareOptions) sampled(p string, level slog.Level) bool {
+	if level > slog.LevelInfo || !matchPath(o.sampledPaths, p) {
+		return true
+	}
+	//nolint:gosec // sampling does not need a cryptographically secure generator
+	return rand.Float64() < o.sampleRate
+}
+
+func matchPath(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func filterHeaders(h http.Header, names []string) http.Header {
+	if len(names) == 0 {
+		return nil
+	}
+	filtered := make(http.Header, len(names))
+	for _, name := range names {
+		if values := h.Values(name); len(values) > 0 {
+			filtered[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type logEntry struct {
+	Level   string         `json:"level"`
+	Request map[string]any `json:"request"`
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []LoggingMiddlewareOption
+		path      string
+		status    int
+		delay     time.Duration
+		wantLevel string
+		wantAttrs map[string]any
+		wantNoLog bool
+	}{
+		{
+			name:      "success:default",
+			path:      "/users/1?page=2",
+			status:    http.StatusNotFound,
+			wantLevel: "INFO",
+			wantAttrs: map[string]any{"method": "POST", "path": "/users/1", "status_code": float64(http.StatusNotFound)},
+		},
+		{
+			name:      "success:default-server-error",
+			path:      "/users/1",
+			status:    http.StatusServiceUnavailable,
+			wantLevel: "ERROR",
+		},
+		{
+			name: "success:fields",
+			opts: []LoggingMiddlewareOption{
+				WithLogFields(LogFieldUserAgent, LogFieldClientIP, LogFieldQuery, LogFieldRoute, LogFieldBytesIn, LogFieldBytesOut),
+				WithLogFields(LogFieldReferer),
+				WithLoggedHeaders("x-tenant", "X-Missing"),
+			},
+			path:      "/users/1?page=2",
+			status:    http.StatusOK,
+			wantLevel: "INFO",
+			wantAttrs: map[string]any{
+				"user_agent": "test-agent",
+				"client_ip":  "192.0.2.1",
+				"query":      "page=2",
+				"route":      "/users/{id}",
+				"bytes_in":   float64(len("request")),
+				"bytes_out":  float64(len("response")),
+				"referer":    "https://example.com/",
+				"headers":    map[string]any{"X-Tenant": "acme"},
+			},
+		},
+		{
+			name:      "success:client-errors-as-warn",
+			opts:      []LoggingMiddlewareOption{WithLevelFunc(StatusLevels(slog.LevelDebug, slog.LevelWarn, slog.LevelError))},
+			path:      "/users/1",
+			status:    http.StatusBadRequest,
+			wantLevel: "WARN",
+		},
+		{
+			name:      "success:slow-request",
+			opts:      []LoggingMiddlewareOption{WithSlowRequestThreshold(time.Millisecond)},
+			path:      "/users/1",
+			status:    http.StatusOK,
+			delay:     2 * time.Millisecond,
+			wantLevel: "WARN",
+		},
+		{
+			name:      "success:skipped",
+			opts:      []LoggingMiddlewareOption{WithSkippedPaths("/healthz", "/internal/*")},
+			path:      "/internal/metrics",
+			status:    http.StatusInternalServerError,
+			wantNoLog: true,
+		},
+		{
+			name:      "success:sampled-out",
+			opts:      []LoggingMiddlewareOption{WithSampledPaths(0, "/users/*")},
+			path:      "/users/1",
+			status:    http.StatusOK,
+			wantNoLog: true,
+		},
+		{
+			name:      "success:sampled-error-logged",
+			opts:      []LoggingMiddlewareOption{WithSampledPaths(0, "/users/*")},
+			path:      "/users/1",
+			status:    http.StatusInternalServerError,
+			wantLevel: "ERROR",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			l := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			r := chi.NewRouter()
+			r.Use(LoggingMiddleware(l, tt.opts...))
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				time.Sleep(tt.delay)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("response"))
+			}
+			r.Post("/users/{id}", handler)
+			r.Post("/internal/metrics", handler)
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader("request"))
+			req.Header.Set("User-Agent", "test-agent")
+			req.Header.Set("Referer", "https://example.com/")
+			req.Header.Set("X-Tenant", "acme")
+			req.RemoteAddr = "192.0.2.1:1234"
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.wantNoLog {
+				assert.Empty(t, logs.String())
+				return
+			}
+			var entry logEntry
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+			assert.Equal(t, tt.wantLevel, entry.Level)
+			for k, v := range tt.wantAttrs {
+				assert.Equal(t, v, entry.Request[k], k)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	httpx "go.strv.io/net/http"
+	"go.strv.io/net/internal"
+)
+
+const (
+	labelMethod = "method"
+	labelStatus = "status"
+	labelRoute  = "route"
+	labelState  = "state"
+
+	// otherMethod replaces non-standard HTTP methods in labels to keep the cardinality bounded.
+	otherMethod = "OTHER"
+
+	minStatusCode     = 100
+	maxStatusCode     = 599
+	statusClassFactor = 100
+)
+
+type Options struct {
+	registry        *Registry
+	namespace       string
+	durationBuckets []float64
+	sizeBuckets     []float64
+	logger          *slog.Logger
+}
+
+type Option func(*Options)
+
+// WithRegistry sets the registry the metrics are registered to, e.g. to expose them together with application metrics.
+// By default, a new registry is created.
+func WithRegistry(r *Registry) Option {
+	return func(o *Options) {
+		o.registry = r
+	}
+}
+
+// WithNamespace sets a prefix of metric names, e.g. "api" results in "api_http_server_requests_total".
+func WithNamespace(namespace string) Option {
+	return func(o *Options) {
+		o.namespace = namespace
+	}
+}
+
+// WithDurationBuckets sets upper bounds (in seconds) of the request duration histogram. Default is DefaultDurationBuckets.
+func WithDurationBuckets(buckets ...float64) Option {
+	return func(o *Options) {
+		o.durationBuckets = buckets
+	}
+}
+
+// WithSizeBuckets sets upper bounds (in bytes) of the request and response size histograms. Default is DefaultSizeBuckets.
+func WithSizeBuckets(buckets ...float64) Option {
+	return func(o *Options) {
+		o.sizeBuckets = buckets
+	}
+}
+
+// WithLogger sets a logger for the response writer created by the middleware.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.logger = l
+	}
+}
+
+// Metrics collects metrics of HTTP requests and server connections.
+type Metrics struct {
+	registry *Registry
+	logger   *slog.Logger
+
+	requests     *CounterVec
+	duration     *HistogramVec
+	inFlight     *GaugeVec
+	requestSize  *HistogramVec
+	responseSize *HistogramVec
+
+	connections      *GaugeVec
+	connectionsTotal *CounterVec
+	connMu           sync.Mutex
+	connStates       map[net.Conn]http.ConnState
+}
+
+// New registers request and connection metrics to the registry:
+//   - http_server_requests_total counter,
+//   - http_server_request_duration_seconds histogram,
+//   - http_server_request_size_bytes histogram,
+//   - http_server_response_size_bytes histogram,
+//   - http_server_requests_in_flight gauge, labeled by method only,
+//   - http_server_connections gauge, labeled by connection state (new, active, idle),
+//   - http_server_connections_total counter of accepted connections.
+//
+// Request metrics are labeled by method, status class (e.g. "2xx") and route pattern.
+func New(opts ...Option) *Metrics {
+	o := Options{
+		durationBuckets: DefaultDurationBuckets,
+		sizeBuckets:     DefaultSizeBuckets,
+		logger:          internal.NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.registry == nil {
+		o.registry = NewRegistry()
+	}
+	name := func(n string) string {
+		if o.namespace == "" {
+			return n
+		}
+		return o.namespace + "_" + n
+	}
+
+	r := o.registry
+	m := &Metrics{
+		registry: r,
+		logger:   o.logger,
+		requests: r.NewCounterVec(name("http_server_requests_total"),
+			"Total number of processed HTTP requests.", labelMethod, labelStatus, labelRoute),
+		duration: r.NewHistogramVec(name("http_server_request_duration_seconds"),
+			"Duration of HTTP requests in seconds.", o.durationBuckets, labelMethod, labelStatus, labelRoute),
+		requestSize: r.NewHistogramVec(name("http_server_request_size_bytes"),
+			"Size of HTTP request bodies in bytes.", o.sizeBuckets, labelMethod, labelStatus, labelRoute),
+		responseSize: r.NewHistogramVec(name("http_server_response_size_bytes"),
+			"Size of HTTP response bodies in bytes.", o.sizeBuckets, labelMethod, labelStatus, labelRoute),
+		inFlight: r.NewGaugeVec(name("http_server_requests_in_flight"),
+			"Number of HTTP requests being processed.", labelMethod),
+		connections: r.NewGaugeVec(name("http_server_connections"),
+			"Number of open client connections by state.", labelState),
+		connectionsTotal: r.NewCounterVec(name("http_server_connections_total"),
+			"Total number of accepted client connections."),
+		connStates: map[net.Conn]http.ConnState{},
+	}
+	for _, state := range []http.ConnState{http.StateNew, http.StateActive, http.StateIdle} {
+		m.connections.Set(0, state.String())
+	}
+	m.connectionsTotal.Add(0)
+	return m
+}
+
+// Handler returns a handler serving all metrics of the registry in Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return m.registry.Handler()
+}
+
+// Middleware records metrics of each request.
+//
+// The route label is the route pattern matched by chi router (e.g. "/users/{id}"), so the middleware has to be
+// registered with chi.Router.Use. Requests not matched by any route have an empty route label.
+// Raw paths are never used as labels to avoid high cardinality.
+// Requests that panic are recorded as well (with 5xx status if no status was written), then the panic is re-raised.
+func (m *Metrics) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method := normalizeMethod(r.Method)
+			m.inFlight.Inc(method)
+			defer m.inFlight.Dec(method)
+
+			rw, ok := w.(*httpx.ResponseWriter)
+			if !ok {
+				rw = httpx.NewResponseWriter(w, m.logger)
+			}
+			responseSize := new(byteCounter)
+			rw.TeeBody(responseSize)
+
+			var requestBody *internal.CountingReadCloser
+			if r.ContentLength < 0 && r.Body != nil && r.Body != http.NoBody {
+				requestBody = &internal.CountingReadCloser{ReadCloser: r.Body}
+				r.Body = requestBody
+			}
+
+			requestStart := time.Now()
+			defer func() {
+				statusCode := rw.StatusCode()
+				re := recover()
+				if re != nil && !rw.HeaderWritten() {
+					statusCode = http.StatusInternalServerError
+				}
+
+				requestSize := max(r.ContentLength, 0)
+				if requestBody != nil {
+					requestSize = requestBody.N
+				}
+				labels := []string{method, statusClass(statusCode), internal.RoutePattern(r)}
+				m.requests.Inc(labels...)
+				m.duration.Observe(time.Since(requestStart).Seconds(), labels...)
+				m.requestSize.Observe(float64(requestSize), labels...)
+				m.responseSize.Observe(float64(*responseSize), labels...)
+
+				if re != nil {
+					panic(re)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// ConnState tracks the number of client connections by state. It can be used as httpx.ServerHooks.ConnState
+// or http.Server.ConnState.
+func (m *Metrics) ConnState(c net.Conn, state http.ConnState) {
+	m.connMu.Lock()
+	defer m.connMu.Unlock()
+
+	if prev, ok := m.connStates[c]; ok {
+		m.connections.Dec(prev.String())
+	}
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(m.connStates, c)
+	default:
+		if state == http.StateNew {
+			m.connectionsTotal.Inc()
+		}
+		m.connStates[c] = state
+		m.connections.Inc(state.String())
+	}
+}
+
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return otherMethod
+	}
+}
+
+// statusClass returns the class of the status code, e.g. "2xx".
+func statusClass(statusCode int) string {
+	if statusCode < minStatusCode || statusCode > maxStatusCode {
+		return "unknown"
+	}
+	return strconv.Itoa(statusCode/statusClassFactor) + "xx"
+}
+
+// byteCounter counts bytes of the response body written through httpx.ResponseWriter.TeeBody.
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
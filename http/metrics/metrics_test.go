@@ -0,0 +1,103 @@
+package metrics_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net/http/metrics"
+)
+
+func scrape(t *testing.T, h http.Handler) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	return rec.Body.String()
+}
+
+func TestMetrics_Middleware(t *testing.T) {
+	m := metrics.New(metrics.WithNamespace("api"), metrics.WithDurationBuckets(1), metrics.WithSizeBuckets(10))
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware())
+	r.Get("/users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("0123456789abcdef"))
+	})
+	r.Post("/users", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	for _, id := range []string{"1", "2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/"+id, nil))
+	}
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("12345"))
+	req.ContentLength = -1
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PROPFIND", "/users", nil))
+
+	out := scrape(t, m.Handler())
+	for _, line := range []string{
+		`api_http_server_requests_total{method="GET",status="2xx",route="/users/{id}"} 2`,
+		`api_http_server_requests_total{method="POST",status="4xx",route="/users"} 1`,
+		`api_http_server_requests_total{method="OTHER",status="4xx",route=""} 1`,
+		`api_http_server_request_duration_seconds_count{method="GET",status="2xx",route="/users/{id}"} 2`,
+		`api_http_server_request_size_bytes_bucket{method="POST",status="4xx",route="/users",le="10"} 1`,
+		`api_http_server_request_size_bytes_sum{method="POST",status="4xx",route="/users"} 5`,
+		`api_http_server_response_size_bytes_bucket{method="GET",status="2xx",route="/users/{id}",le="10"} 0`,
+		`api_http_server_response_size_bytes_sum{method="GET",status="2xx",route="/users/{id}"} 32`,
+		`api_http_server_requests_in_flight{method="GET"} 0`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+	assert.NotContains(t, out, `route="/users/1"`)
+}
+
+func TestMetrics_ConnState(t *testing.T) {
+	m := metrics.New()
+	c1, c2 := &net.TCPConn{}, &net.TCPConn{}
+
+	m.ConnState(c1, http.StateNew)
+	m.ConnState(c1, http.StateActive)
+	m.ConnState(c2, http.StateNew)
+	m.ConnState(c2, http.StateActive)
+	m.ConnState(c2, http.StateIdle)
+	m.ConnState(c1, http.StateHijacked)
+
+	out := scrape(t, m.Handler())
+	for _, line := range []string{
+		`http_server_connections{state="active"} 0`,
+		`http_server_connections{state="idle"} 1`,
+		`http_server_connections{state="new"} 0`,
+		`http_server_connections_total 2`,
+	} {
+		assert.Contains(t, out, line+"\n")
+	}
+}
+
+func TestMetrics_Middleware_Panic(t *testing.T) {
+	m := metrics.New()
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware())
+	r.Get("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+
+	assert.PanicsWithValue(t, "boom", func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	})
+
+	out := scrape(t, m.Handler())
+	assert.Contains(t, out, `http_server_requests_total{method="GET",status="5xx",route="/panic"} 1`+"\n")
+	assert.Contains(t, out, `http_server_requests_in_flight{method="GET"} 0`+"\n")
+}
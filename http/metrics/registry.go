@@ -0,0 +1,326 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	httpx "go.strv.io/net/http"
+)
+
+// expositionContentType is the content type of the Prometheus text exposition format.
+const expositionContentType = string(httpx.TextPlain) + "; version=0.0.4; charset=" + string(httpx.UTF8)
+
+// labelValuesSeparator joins label values into a key of a series. It cannot occur in valid UTF-8.
+const labelValuesSeparator = "\xff"
+
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// DefaultDurationBuckets are upper bounds of duration histograms in seconds.
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are upper bounds of size histograms in bytes.
+var DefaultSizeBuckets = []float64{100, 1_000, 10_000, 100_000, 1_000_000, 10_000_000}
+
+// metric is a family of series with the same name and label names.
+type metric interface {
+	name() string
+	write(b *strings.Builder)
+}
+
+// Registry holds metrics and exposes them in Prometheus text exposition format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewCounterVec registers a counter, a value that only increases, partitioned by the label names.
+// It panics if the name is invalid or already registered.
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{vec: newVec[float64](name, help, "counter", labelNames)}
+	r.register(c)
+	return c
+}
+
+// NewGaugeVec registers a gauge, a value that can go up and down, partitioned by the label names.
+// It panics if the name is invalid or already registered.
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{vec: newVec[float64](name, help, "gauge", labelNames)}
+	r.register(g)
+	return g
+}
+
+// NewHistogramVec registers a histogram counting observations into buckets with the given upper bounds,
+// partitioned by the label names. It panics if the name is invalid or already registered.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	h := &HistogramVec{
+		vec:     newVec[*histogram](name, help, "histogram", labelNames),
+		buckets: buckets,
+	}
+	r.register(h)
+	return h
+}
+
+func (r *Registry) register(m metric) {
+	if !metricNameRegexp.MatchString(m.name()) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", m.name()))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.metrics {
+		if existing.name() == m.name() {
+			panic(fmt.Sprintf("metrics: metric %q already registered", m.name()))
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo writes all metrics in Prometheus text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	b := &strings.Builder{}
+	for _, m := range metrics {
+		m.write(b)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler returns a handler serving the metrics in Prometheus text exposition format, e.g. on /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(httpx.Header.ContentType, expositionContentType)
+		_, _ = r.WriteTo(w)
+	})
+}
+
+// vec holds series of a metric, keyed by label values.
+type vec[T any] struct {
+	metricName string
+	help       string
+	typ        string
+	labelNames []string
+
+	mu     sync.Mutex
+	series map[string]T
+}
+
+func newVec[T any](name, help, typ string, labelNames []string) vec[T] {
+	return vec[T]{
+		metricName: name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		series:     map[string]T{},
+	}
+}
+
+func (v *vec[T]) name() string {
+	return v.metricName
+}
+
+func (v *vec[T]) key(labelValues []string) string {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.metricName, len(v.labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, labelValuesSeparator)
+}
+
+// sortedKeys returns keys of the series in a stable order. It must be called with the lock held.
+func (v *vec[T]) sortedKeys() []string {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (v *vec[T]) writeHeader(b *strings.Builder) {
+	b.WriteString("# HELP " + v.metricName + " " + escapeHelp(v.help) + "\n")
+	b.WriteString("# TYPE " + v.metricName + " " + v.typ + "\n")
+}
+
+// writeSample writes a single sample line, the extra label (e.g. le of a histogram bucket) is appended if not empty.
+func (v *vec[T]) writeSample(b *strings.Builder, suffix, key string, extraName, extraValue string, value float64) {
+	b.WriteString(v.metricName + suffix)
+	var values []string
+	if len(v.labelNames) > 0 {
+		values = strings.Split(key, labelValuesSeparator)
+	}
+	if len(values) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, name := range v.labelNames {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(name + `="` + escapeLabelValue(values[i]) + `"`)
+		}
+		if extraName != "" {
+			if len(values) > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	vec[float64]
+}
+
+// Inc increments the counter of the series with the label values by 1.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative value to the counter of the series with the label values.
+func (c *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	key := c.key(labelValues)
+	c.mu.Lock()
+	c.series[key] += value
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(b)
+	for _, k := range c.sortedKeys() {
+		c.writeSample(b, "", k, "", "", c.series[k])
+	}
+}
+
+// GaugeVec is a gauge partitioned by labels.
+type GaugeVec struct {
+	vec[float64]
+}
+
+// Set sets the gauge of the series with the label values.
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	g.series[key] = value
+	g.mu.Unlock()
+}
+
+// Add adds a value (which can be negative) to the gauge of the series with the label values.
+func (g *GaugeVec) Add(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+	g.mu.Lock()
+	g.series[key] += value
+	g.mu.Unlock()
+}
+
+// Inc increments the gauge of the series with the label values by 1.
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec decrements the gauge of the series with the label values by 1.
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *GaugeVec) write(b *strings.Builder) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(b)
+	for _, k := range g.sortedKeys() {
+		g.writeSample(b, "", k, "", "", g.series[k])
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	vec[*histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe adds an observation to the histogram of the series with the label values.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) write(b *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(b)
+	for _, k := range h.sortedKeys() {
+		s := h.series[k]
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += s.counts[i]
+			h.writeSample(b, "_bucket", k, "le", formatFloat(upperBound), float64(cumulative))
+		}
+		h.writeSample(b, "_bucket", k, "le", "+Inf", float64(s.count))
+		h.writeSample(b, "_sum", k, "", "", s.sum)
+		h.writeSample(b, "_count", k, "", "", float64(s.count))
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+var (
+	helpReplacer       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
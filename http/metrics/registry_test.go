@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("jobs_total", "Total number of jobs.\nMultiline.", "queue")
+	g := r.NewGaugeVec("temperature", "Current temperature.")
+	h := r.NewHistogramVec("latency_seconds", "Latency.", []float64{1, 0.1}, "path")
+
+	c.Inc(`emails "high"`)
+	c.Add(2, "default")
+	g.Set(21.5)
+	g.Dec()
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(5, "/a")
+
+	b := &strings.Builder{}
+	_, err := r.WriteTo(b)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP jobs_total Total number of jobs.\nMultiline.
+# TYPE jobs_total counter
+jobs_total{queue="default"} 2
+jobs_total{queue="emails \"high\""} 1
+# HELP temperature Current temperature.
+# TYPE temperature gauge
+temperature 20.5
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/a",le="0.1"} 1
+latency_seconds_bucket{path="/a",le="1"} 2
+latency_seconds_bucket{path="/a",le="+Inf"} 3
+latency_seconds_sum{path="/a"} 5.55
+latency_seconds_count{path="/a"} 3
+`, b.String())
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("jobs_total", "Total number of jobs.")
+
+	assert.Panics(t, func() { r.NewGaugeVec("jobs_total", "Duplicate.") })
+	assert.Panics(t, func() { r.NewGaugeVec("jobs-total", "Invalid name.") })
+	assert.Panics(t, func() { r.NewCounterVec("labeled_total", "Labeled.", "queue").Inc() })
+}
@@ -1,50 +1,177 @@
 package http
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
 	"go.strv.io/net"
+	"go.strv.io/net/internal"
 )
 
 const (
-	requestIDLogFieldName = "request_id"
+	requestIDLogFieldName       = "request_id"
+	parentRequestIDLogFieldName = "parent_request_id"
+	traceIDLogFieldName         = "trace_id"
 )
 
+const defaultMaxRequestIDLength = 128
+
 // RequestIDFunc is used for obtaining a request ID from the HTTP header.
 type RequestIDFunc func(h http.Header) string
 
+type RequestIDMiddlewareOptions struct {
+	header     string
+	generator  net.RequestIDGenerator
+	maxLength  int
+	validate   func(id string) bool
+	keepParent bool
+}
+
+type RequestIDMiddlewareOption func(*RequestIDMiddlewareOptions)
+
+// WithRequestIDHeader sets the header the request ID is read from (if RequestIDFunc is nil)
+// and written to. Default is X-Request-Id.
+func WithRequestIDHeader(name string) RequestIDMiddlewareOption {
+	return func(o *RequestIDMiddlewareOptions) {
+		o.header = name
+	}
+}
+
+// WithRequestIDGenerator sets the generator of new request IDs, e.g. net.NewUUIDv7RequestID
+// or net.PrefixedRequestIDGenerator("req_", 16). Default is net.NewRequestID (random UUID).
+func WithRequestIDGenerator(g net.RequestIDGenerator) RequestIDMiddlewareOption {
+	return func(o *RequestIDMiddlewareOptions) {
+		o.generator = g
+	}
+}
+
+// WithRequestIDMaxLength sets the maximum length of incoming request IDs. Default is 128.
+func WithRequestIDMaxLength(n int) RequestIDMiddlewareOption {
+	return func(o *RequestIDMiddlewareOptions) {
+		o.maxLength = n
+	}
+}
+
+// WithRequestIDValidator sets a function validating incoming request IDs in addition to the length.
+// Default is ValidRequestIDChars.
+func WithRequestIDValidator(f func(id string) bool) RequestIDMiddlewareOption {
+	return func(o *RequestIDMiddlewareOptions) {
+		o.validate = f
+	}
+}
+
+// WithParentRequestID makes the middleware always generate a new request ID and keep the valid incoming one
+// as the parent request ID (see net.ParentRequestIDFromCtx), logged as parent_request_id.
+func WithParentRequestID() RequestIDMiddlewareOption {
+	return func(o *RequestIDMiddlewareOptions) {
+		o.keepParent = true
+	}
+}
+
+// ValidRequestIDChars reports whether the request ID consists only of ASCII letters, digits, '-', '_', '.' and ':'.
+func ValidRequestIDChars(id string) bool {
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // RequestIDMiddleware saves request ID into the request context and response header.
 // If context already contains request ID, next handler is called.
-// If the user provided function returns empty request ID, a new one is generated.
-func RequestIDMiddleware(f RequestIDFunc) func(http.Handler) http.Handler {
+// The incoming request ID is obtained by the user provided function, or from the header set by WithRequestIDHeader
+// if the function is nil. If it is empty, longer than WithRequestIDMaxLength or rejected by WithRequestIDValidator,
+// a new one is generated by WithRequestIDGenerator.
+func RequestIDMiddleware(f RequestIDFunc, opts ...RequestIDMiddlewareOption) func(http.Handler) http.Handler {
+	options := RequestIDMiddlewareOptions{
+		header:    Header.XRequestID,
+		generator: net.NewRequestID,
+		maxLength: defaultMaxRequestIDLength,
+		validate:  ValidRequestIDChars,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if f == nil {
+		f = func(h http.Header) string {
+			return h.Get(options.header)
+		}
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if requestID := net.RequestIDFromCtx(r.Context()); requestID != "" {
-				w.Header().Set(Header.XRequestID, requestID)
+				w.Header().Set(options.header, requestID)
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			var requestID string
-			if rID := f(r.Header); rID != "" {
-				requestID = rID
-			} else {
-				requestID = net.NewRequestID()
+			ctx := r.Context()
+			requestID := f(r.Header)
+			if requestID != "" && (len(requestID) > options.maxLength || !options.validate(requestID)) {
+				requestID = ""
+			}
+			if options.keepParent && requestID != "" {
+				ctx = net.WithParentRequestID(ctx, requestID)
+				requestID = ""
+			}
+			if requestID == "" {
+				requestID = options.generator()
 			}
 
-			w.Header().Set(Header.XRequestID, requestID)
+			w.Header().Set(options.header, requestID)
 
-			ctx := net.WithRequestID(r.Context(), requestID)
+			ctx = net.WithRequestID(ctx, requestID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+const defaultPanicErrCode = "ERR_INTERNAL"
+
+// PanicReport describes a panic recovered by RecoverMiddleware.
+type PanicReport struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+	// Request is the request being processed. Its body may have been already read.
+	Request *http.Request
+	// RequestID and TraceID identify the request, they are empty if not set by the middlewares.
+	RequestID string
+	TraceID   string
+	// Principal is the authenticated principal saved by net.WithPrincipal, empty if not authenticated.
+	Principal string
+}
+
+// PanicReporter reports recovered panics, e.g. to an error tracking service.
+// ReportPanic is called synchronously before the error response is written, so it should not block.
+type PanicReporter interface {
+	ReportPanic(ctx context.Context, report PanicReport)
+}
+
+// PanicReporterFunc is an adapter to allow the use of ordinary functions as PanicReporter.
+type PanicReporterFunc func(ctx context.Context, report PanicReport)
+
+func (f PanicReporterFunc) ReportPanic(ctx context.Context, report PanicReport) {
+	f(ctx, report)
+}
+
 type RecoverMiddlewareOptions struct {
 	enableStackTrace bool
+	errCode          string
+	reporters        []PanicReporter
 }
 
 type RecoverMiddlewareOption func(*RecoverMiddlewareOptions)
@@ -55,95 +182,206 @@ func WithStackTrace() RecoverMiddlewareOption {
 	}
 }
 
+// WithPanicErrorCode sets the error code of the error response written after a panic. Default is ERR_INTERNAL.
+func WithPanicErrorCode(code string) RecoverMiddlewareOption {
+	return func(opts *RecoverMiddlewareOptions) {
+		opts.errCode = code
+	}
+}
+
+// WithPanicReporter adds a reporter called with each recovered panic.
+func WithPanicReporter(r PanicReporter) RecoverMiddlewareOption {
+	return func(opts *RecoverMiddlewareOptions) {
+		opts.reporters = append(opts.reporters, r)
+	}
+}
+
 // RecoverMiddleware calls next handler and recovers from a panic.
-// If a panic occurs, log this event, set http.StatusInternalServerError as a status code
-// and save a panic object into the response writer.
+// If a panic occurs, log this event, report it to reporters set by WithPanicReporter and save a panic object
+// into the response writer. If the response headers have not been sent yet, an error response with
+// http.StatusInternalServerError, the request ID and the error code set by WithPanicErrorCode is written.
+// If the context contains an OpenTelemetry span, the panic is recorded as an exception event of the span.
+//
+// Panics with http.ErrAbortHandler are not recovered, so the server aborts the response as intended.
 func RecoverMiddleware(l *slog.Logger, opts ...RecoverMiddlewareOption) func(http.Handler) http.Handler {
-	options := RecoverMiddlewareOptions{}
+	options := RecoverMiddlewareOptions{
+		errCode: defaultPanicErrCode,
+	}
 	for _, o := range opts {
 		o(&options)
 	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw, ok := w.(*ResponseWriter)
+			if !ok {
+				rw = NewResponseWriter(w, l)
+			}
 			defer func() {
-				if re := recover(); re != nil {
-					rw, ok := w.(*ResponseWriter)
-					if !ok {
-						rw = NewResponseWriter(w, l)
-					}
-
-					rw.SetPanicObject(re)
-					rw.WriteHeader(http.StatusInternalServerError)
-
-					logAttributes := []slog.Attr{
-						slog.String(requestIDLogFieldName, net.RequestIDFromCtx(r.Context())),
-						slog.Any("error", re),
-					}
-					if options.enableStackTrace {
-						logAttributes = append(logAttributes, slog.String("stack_trace", string(debug.Stack())))
-					}
-					l.LogAttrs(r.Context(), slog.LevelError, "panic recover", logAttributes...)
+				re := recover()
+				if re == nil {
+					return
+				}
+				if re == http.ErrAbortHandler {
+					panic(re)
+				}
+
+				stack := debug.Stack()
+				requestID := net.RequestIDFromCtx(r.Context())
+				traceID := net.TraceIDFromCtx(r.Context())
+				rw.SetPanicObject(re)
+
+				logAttributes := []slog.Attr{
+					slog.String(requestIDLogFieldName, requestID),
+					slog.Any("error", re),
+				}
+				if traceID != "" {
+					logAttributes = append(logAttributes, slog.String(traceIDLogFieldName, traceID))
+				}
+				if options.enableStackTrace {
+					logAttributes = append(logAttributes, slog.String("stack_trace", string(stack)))
+				}
+				l.LogAttrs(r.Context(), slog.LevelError, "panic recover", logAttributes...)
+
+				recordPanic(trace.SpanFromContext(r.Context()), re, options.enableStackTrace)
+
+				report := PanicReport{
+					Value:     re,
+					Stack:     stack,
+					Request:   r,
+					RequestID: requestID,
+					TraceID:   traceID,
+					Principal: net.PrincipalFromCtx(r.Context()),
+				}
+				for _, reporter := range options.reporters {
+					reporter.ReportPanic(r.Context(), report)
+				}
+
+				if rw.HeaderWritten() {
+					return
+				}
+				if err := WriteErrorResponse(
+					rw,
+					http.StatusInternalServerError,
+					WithRequestID(requestID),
+					WithErrorCode(options.errCode),
+				); err != nil {
+					l.ErrorContext(r.Context(), "writing panic response", slog.Any("error", err))
 				}
 			}()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(rw, r)
 		})
 	}
 }
 
+// recordPanic adds an exception event to the span and sets its status to error.
+func recordPanic(span trace.Span, re any, withStackTrace bool) {
+	if !span.IsRecording() {
+		return
+	}
+	err, ok := re.(error)
+	if !ok {
+		err = fmt.Errorf("%v", re)
+	}
+	span.RecordError(err, trace.WithStackTrace(withStackTrace))
+	span.SetStatus(codes.Error, "panic: "+err.Error())
+}
+
 // LoggingMiddleware logs:
 //   - URL path
 //   - HTTP method
 //   - Request ID
+//   - Client IP (if resolved by RealIPMiddleware)
+//   - Trace and span ID (if set by TraceContextMiddleware)
 //   - Duration of a request
 //   - HTTP status code
 //   - Error object if exists
 //   - Panic object if exists
+//   - Attributes added by inner middlewares or handlers using ResponseWriter.AddLogAttrs
+//   - Additional fields and headers enabled by WithLogFields and WithLoggedHeaders
 //
-// If the status code >= http.StatusInternalServerError, logs with error level, info otherwise.
-func LoggingMiddleware(l *slog.Logger) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			rw, ok := w.(*ResponseWriter)
-			if !ok {
-				rw = NewResponseWriter(w, l)
-			}
-
-			requestStart := time.Now()
-			next.ServeHTTP(rw, r)
-			statusCode := rw.StatusCode()
-			requestID := net.RequestIDFromCtx(r.Context())
-
-			ld := RequestData{
-				Path:               r.URL.EscapedPath(),
-				Method:             r.Method,
-				RequestID:          requestID,
-				Duration:           time.Since(requestStart),
-				ResponseStatusCode: statusCode,
-			}
+// By default, if the status code >= http.StatusInternalServerError, logs with error level, info otherwise.
+// The level can be changed by WithLevelFunc and WithSlowRequestThreshold, and requests can be skipped
+// or sampled by path using WithSkippedPaths and WithSampledPaths.
+func LoggingMiddleware(l *slog.Logger, opts ...LoggingMiddlewareOption) func(http.Handler) http.Handler {
+	options := newLoggingMiddlewareOptions(opts)
+	return requestLogMiddleware(l, options, func(r *http.Request, rw *ResponseWriter, rd RequestData, level slog.Level) {
+		withRequestData(l, rw, rd, options.format).Log(r.Context(), level, "request processed")
+	})
+}
 
-			if statusCode >= http.StatusInternalServerError {
-				withRequestData(l, rw, ld).ErrorContext(r.Context(), "request processed")
-			} else {
-				withRequestData(l, rw, ld).InfoContext(r.Context(), "request processed")
-			}
-		})
+// newRequestData collects data of the processed request. requestBody is nil if the request has no body.
+func newRequestData(r *http.Request, rw *ResponseWriter, start time.Time, requestBody *internal.CountingReadCloser) RequestData {
+	trace, _ := net.TraceFromCtx(r.Context())
+	rd := RequestData{
+		Path:               r.URL.EscapedPath(),
+		Method:             r.Method,
+		RequestID:          net.RequestIDFromCtx(r.Context()),
+		ParentRequestID:    net.ParentRequestIDFromCtx(r.Context()),
+		ClientIP:           net.ClientIPFromCtx(r.Context()),
+		RemoteAddr:         r.RemoteAddr,
+		TraceID:            trace.TraceID,
+		SpanID:             trace.SpanID,
+		Principal:          net.PrincipalFromCtx(r.Context()),
+		Attrs:              rw.RequestLogAttrs(),
+		StartTime:          start,
+		Duration:           time.Since(start),
+		ResponseStatusCode: rw.StatusCode(),
+		UserAgent:          r.UserAgent(),
+		Referer:            r.Referer(),
+		Query:              r.URL.RawQuery,
+		Proto:              r.Proto,
+		Route:              internal.RoutePattern(r),
+		BytesOut:           rw.BytesWritten(),
+	}
+	if requestBody != nil {
+		rd.BytesIn = requestBody.N
 	}
+	return rd
 }
 
 // RequestData contains processed request data for logging purposes.
 // Path is path from URL of the request.
 // Method is HTTP request method.
+// StartTime is when the processing of the request started.
 // Duration is how long it took to process whole request.
 // ResponseStatusCode is HTTP status code which was returned.
 // RequestID is unique identifier of request.
+// ParentRequestID is the request ID received from the upstream service, set if WithParentRequestID is used.
+// ClientIP is address of the client resolved by RealIPMiddleware, empty if not resolved.
+// RemoteAddr is network address of the peer that sent the request.
+// TraceID and SpanID identify the request within a distributed trace, empty if not set by TraceContextMiddleware.
+// Principal is the authenticated principal saved by net.WithPrincipal, empty if not authenticated.
+// UserAgent, Referer, Query and Proto are the user agent, the referer, the raw query and the protocol of the request.
+// Route is the route pattern matched by chi router (e.g. "/users/{id}"), empty if not routed by chi.
+// BytesIn and BytesOut are sizes of the request and response bodies.
+// Headers are request headers allowed by WithLoggedHeaders.
+// Fields are the optional fields included in the log (see WithLogFields).
+// Attrs are additional attributes added by ResponseWriter.AddRequestLogAttrs (e.g. by BodyLoggingMiddleware).
 // Err is error object containing error message.
 // Panic is panic object containing error message.
 type RequestData struct {
 	Path               string
 	Method             string
+	StartTime          time.Time
 	Duration           time.Duration
 	ResponseStatusCode int
 	RequestID          string
+	ParentRequestID    string
+	ClientIP           string
+	RemoteAddr         string
+	TraceID            string
+	SpanID             string
+	Principal          string
+	UserAgent          string
+	Referer            string
+	Query              string
+	Proto              string
+	Route              string
+	BytesIn            int64
+	BytesOut           int64
+	Headers            http.Header
+	Fields             LogField
+	Attrs              []slog.Attr
 }
 
 func (r RequestData) LogValue() slog.Value {
@@ -154,11 +392,66 @@ func (r RequestData) LogValue() slog.Value {
 		slog.Int("status_code", r.ResponseStatusCode),
 		slog.Int64("duration_ms", r.Duration.Milliseconds()),
 	}
+	if r.ParentRequestID != "" {
+		attr = append(attr, slog.String(parentRequestIDLogFieldName, r.ParentRequestID))
+	}
+	if clientIP := r.clientIP(); clientIP != "" {
+		attr = append(attr, slog.String("client_ip", clientIP))
+	}
+	if r.TraceID != "" {
+		attr = append(attr, slog.String(traceIDLogFieldName, r.TraceID), slog.String("span_id", r.SpanID))
+	}
+	if r.Fields.has(LogFieldUserAgent) {
+		attr = append(attr, slog.String("user_agent", r.UserAgent))
+	}
+	if r.Fields.has(LogFieldReferer) {
+		attr = append(attr, slog.String("referer", r.Referer))
+	}
+	if r.Fields.has(LogFieldQuery) {
+		attr = append(attr, slog.String("query", r.Query))
+	}
+	if r.Fields.has(LogFieldRoute) {
+		attr = append(attr, slog.String("route", r.Route))
+	}
+	if r.Fields.has(LogFieldBytesIn) {
+		attr = append(attr, slog.Int64("bytes_in", r.BytesIn))
+	}
+	if r.Fields.has(LogFieldBytesOut) {
+		attr = append(attr, slog.Int64("bytes_out", r.BytesOut))
+	}
+	if len(r.Headers) > 0 {
+		headers := make([]any, 0, len(r.Headers))
+		for name, values := range r.Headers {
+			headers = append(headers, slog.String(name, strings.Join(values, ", ")))
+		}
+		attr = append(attr, slog.Group("headers", headers...))
+	}
+	attr = append(attr, r.Attrs...)
 	return slog.GroupValue(attr...)
 }
 
+// clientIP returns the resolved client IP, or the host of RemoteAddr if LogFieldClientIP is enabled.
+func (r RequestData) clientIP() string {
+	if r.ClientIP != "" || !r.Fields.has(LogFieldClientIP) {
+		return r.ClientIP
+	}
+	return r.remoteIP()
+}
+
+// remoteIP returns the resolved client IP, or the host of RemoteAddr if not resolved.
+func (r RequestData) remoteIP() string {
+	if r.ClientIP != "" {
+		return r.ClientIP
+	}
+	if addr := parseHostAddr(r.RemoteAddr); addr.IsValid() {
+		return addr.String()
+	}
+	return ""
+}
+
 // withRequestData returns slog with filled fields.
-func withRequestData(l *slog.Logger, rw *ResponseWriter, rd RequestData) *slog.Logger {
+// If the format is set, its attributes are logged instead of the request group.
+func withRequestData(l *slog.Logger, rw *ResponseWriter, rd RequestData, format AccessLogFormat) *slog.Logger {
 	errorObject := rw.ErrorObject()
 	panicObject := rw.PanicObject()
 	if errorObject != nil {
@@ -167,5 +460,14 @@ func withRequestData(l *slog.Logger, rw *ResponseWriter, rd RequestData) *slog.L
 	if panicObject != nil {
 		l = l.With("panic", panicObject)
 	}
+	for _, attr := range rw.LogAttrs() {
+		l = l.With(attr)
+	}
+	if format != nil {
+		for _, attr := range format.LogAttrs(rd) {
+			l = l.With(attr)
+		}
+		return l
+	}
 	return l.With("request", rd)
 }
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+	"go.strv.io/net/internal"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []RecoverMiddlewareOption
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   map[string]any
+		wantRaw    string
+	}{
+		{
+			name: "success:error-response",
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody: map[string]any{
+				"requestId": "request-1",
+				"errorCode": "ERR_INTERNAL",
+			},
+		},
+		{
+			name: "success:custom-error-code",
+			opts: []RecoverMiddlewareOption{WithPanicErrorCode("ERR_PANIC")},
+			handler: func(http.ResponseWriter, *http.Request) {
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantBody: map[string]any{
+				"requestId": "request-1",
+				"errorCode": "ERR_PANIC",
+			},
+		},
+		{
+			name: "success:headers-already-sent",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte("partial"))
+				panic("boom")
+			},
+			wantStatus: http.StatusOK,
+			wantRaw:    "partial",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []PanicReport
+			reporter := PanicReporterFunc(func(_ context.Context, report PanicReport) {
+				reports = append(reports, report)
+			})
+			opts := append([]RecoverMiddlewareOption{WithPanicReporter(reporter)}, tt.opts...)
+			h := RecoverMiddleware(internal.NewNopLogger(), opts...)(tt.handler)
+
+			r := httptest.NewRequest(http.MethodGet, "/users", nil)
+			r = r.WithContext(net.WithPrincipal(net.WithRequestID(r.Context(), "request-1"), "alice"))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantBody != nil {
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantBody, body)
+			} else {
+				assert.Equal(t, tt.wantRaw, w.Body.String())
+			}
+
+			require.Len(t, reports, 1)
+			assert.Equal(t, "boom", reports[0].Value)
+			assert.Equal(t, "request-1", reports[0].RequestID)
+			assert.Equal(t, "alice", reports[0].Principal)
+			assert.Equal(t, "/users", reports[0].Request.URL.Path)
+			assert.NotEmpty(t, reports[0].Stack)
+		})
+	}
+}
+
+func TestRecoverMiddleware_ErrAbortHandler(t *testing.T) {
+	reported := false
+	h := RecoverMiddleware(
+		internal.NewNopLogger(),
+		WithPanicReporter(PanicReporterFunc(func(context.Context, PanicReport) { reported = true })),
+	)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	w := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.False(t, reported)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		f             RequestIDFunc
+		opts          []RequestIDMiddlewareOption
+		header        string
+		value         string
+		wantHeader    string
+		wantRequestID string
+		wantParentID  string
+	}{
+		{
+			name:          "success:incoming",
+			header:        Header.XRequestID,
+			value:         "upstream-1",
+			wantHeader:    Header.XRequestID,
+			wantRequestID: "upstream-1",
+		},
+		{
+			name: "success:request-id-func",
+			f: func(h http.Header) string {
+				return h.Get("X-Correlation-Id")
+			},
+			header:        "X-Correlation-Id",
+			value:         "upstream-1",
+			wantHeader:    Header.XRequestID,
+			wantRequestID: "upstream-1",
+		},
+		{
+			name:          "success:custom-header",
+			opts:          []RequestIDMiddlewareOption{WithRequestIDHeader("X-Correlation-Id")},
+			header:        "X-Correlation-Id",
+			value:         "upstream-1",
+			wantHeader:    "X-Correlation-Id",
+			wantRequestID: "upstream-1",
+		},
+		{
+			name:          "success:generated",
+			opts:          []RequestIDMiddlewareOption{WithRequestIDGenerator(func() string { return "generated" })},
+			wantHeader:    Header.XRequestID,
+			wantRequestID: "generated",
+		},
+		{
+			name:          "success:invalid-chars-regenerated",
+			opts:          []RequestIDMiddlewareOption{WithRequestIDGenerator(func() string { return "generated" })},
+			header:        Header.XRequestID,
+			value:         "id\nwith<script>",
+			wantHeader:    Header.XRequestID,
+			wantRequestID: "generated",
+		},
+		{
+			name: "success:too-long-regenerated",
+			opts: []RequestIDMiddlewareOption{
+				WithRequestIDGenerator(func() string { return "generated" }),
+				WithRequestIDMaxLength(8),
+			},
+			header:        Header.XRequestID,
+			value:         "upstream-1",
+			wantHeader:    Header.XRequestID,
+			wantRequestID: "generated",
+		},
+		{
+			name: "success:custom-validator",
+			opts: []RequestIDMiddlewareOption{
+				WithRequestIDGenerator(func() string { return "generated" }),
+				WithRequestIDValidator(func(id string) bool { return strings.HasPrefix(id, "req_") }),
+			},
+			header:        Header.XRequestID,
+			value:         "upstream-1",
+			wantHeader:    Header.XRequestID,
+			wantRequestID: "generated",
+		},
+		{
+			name: "success:parent",
+			opts: []RequestIDMiddlewareOption{
+				WithRequestIDGenerator(func() string { return "generated" }),
+				WithParentRequestID(),
+			},
+			header:        Header.XRequestID,
+			value:         "upstream-1",
+			wantHeader:    Header.XRequestID,
+			wantRequestID: "generated",
+			wantParentID:  "upstream-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requestID, parentID string
+			h := RequestIDMiddleware(tt.f, tt.opts...)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				requestID = net.RequestIDFromCtx(r.Context())
+				parentID = net.ParentRequestIDFromCtx(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantRequestID, requestID)
+			assert.Equal(t, tt.wantParentID, parentID)
+			assert.Equal(t, tt.wantRequestID, w.Header().Get(tt.wantHeader))
+		})
+	}
+}
+
+func TestRequestData_LogValue(t *testing.T) {
+	rd := RequestData{RequestID: "request-2", ParentRequestID: "request-1"}
+
+	attrs := map[string]string{}
+	for _, a := range rd.LogValue().Group() {
+		attrs[a.Key] = a.Value.String()
+	}
+	assert.Equal(t, "request-2", attrs["id"])
+	assert.Equal(t, "request-1", attrs["parent_request_id"])
+	assert.NotContains(t, attrs, "parent_id")
+}
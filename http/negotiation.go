@@ -0,0 +1,277 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const errCodeNotAcceptable = "ERR_NOT_ACCEPTABLE"
+
+// Ranks of media ranges, more specific ranges have higher rank.
+const (
+	rankAnyType = iota
+	rankAnySubtype
+	rankSubtype
+)
+
+var (
+	// ErrNotAcceptable is set as the error object of 406 Not Acceptable response written by WriteResponse
+	// with WithNegotiation, if none of the encoders is acceptable for the client.
+	ErrNotAcceptable = errors.New("not acceptable")
+	// ErrUnsupportedMediaType is returned by DecodeRequestBody if none of the decoders accepts
+	// Content-Type of the request.
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// Encoder encodes responses of the content types. The first content type is preferred
+// if the client accepts any of them (e.g. */*).
+type Encoder struct {
+	ContentTypes []ContentType
+	EncodeFunc   EncodeFunc
+	// Binary encoders write responses without charset in Content-Type header.
+	Binary bool
+	// CanEncode reports whether the value can be encoded (e.g. only proto.Message by protobuf encoder).
+	// Encoders that cannot encode the response are not negotiated. Nil means any value can be encoded.
+	CanEncode func(data any) bool
+}
+
+var (
+	JSONEncoder = Encoder{ContentTypes: []ContentType{ApplicationJSON, TextJSON}, EncodeFunc: EncodeJSON}
+	XMLEncoder  = Encoder{ContentTypes: []ContentType{ApplicationXML, TextXML}, EncodeFunc: EncodeXML}
+	YAMLEncoder = Encoder{
+		ContentTypes: []ContentType{ApplicationYAML, ApplicationXYAML, TextYAML, TextXYAML},
+		EncodeFunc:   EncodeYAML,
+	}
+)
+
+// DefaultEncoders are used by WithNegotiation and WithErrorNegotiation if no encoders are passed.
+func DefaultEncoders() []Encoder {
+	return []Encoder{JSONEncoder, XMLEncoder, YAMLEncoder}
+}
+
+// WithNegotiation selects the encoder and the content type of the response according to Accept header
+// of the request. Encoders are preferred in the given order if the client accepts more of them equally,
+// DefaultEncoders are used if none is given. Encoders that cannot encode the data (see Encoder.CanEncode)
+// are skipped. If none of the encoders is acceptable, WriteResponse writes 406 Not Acceptable error response instead.
+// Vary header is extended by Accept, so caches store responses in different content types separately.
+func WithNegotiation(r *http.Request, encoders ...Encoder) ResponseOption {
+	return func(o *ResponseOptions) {
+		o.varyAccept = true
+		o.negotiate = func(o *ResponseOptions, data any) {
+			contentType, encoder, ok := negotiate(r, encoders, data)
+			if !ok {
+				o.notAcceptable = true
+				return
+			}
+			o.ContentType = contentType
+			o.EncodeFunc = encoder.EncodeFunc
+			if encoder.Binary {
+				o.CharsetType = ""
+			}
+		}
+	}
+}
+
+// WithErrorNegotiation selects the encoder and the content type of the error response according to Accept header
+// of the request, see WithNegotiation. If none of the encoders is acceptable, the error response is written
+// with the default content type, as the error should be reported to the client anyway.
+// Vary header is extended by Accept, see WithNegotiation.
+func WithErrorNegotiation(r *http.Request, encoders ...Encoder) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.varyAccept = true
+		if contentType, encoder, ok := negotiate(r, encoders, ErrorResponseOptions{}); ok {
+			o.ContentType = contentType
+			o.EncodeFunc = encoder.EncodeFunc
+			if encoder.Binary {
+				o.CharsetType = ""
+			}
+		}
+	}
+}
+
+// Decoder decodes request bodies of the content types.
+type Decoder struct {
+	ContentTypes []ContentType
+	DecodeFunc   DecodeFunc
+}
+
+var (
+	JSONDecoder = Decoder{ContentTypes: []ContentType{ApplicationJSON, TextJSON}, DecodeFunc: DecodeJSONBody}
+	XMLDecoder  = Decoder{ContentTypes: []ContentType{ApplicationXML, TextXML}, DecodeFunc: DecodeXMLBody}
+	YAMLDecoder = Decoder{
+		ContentTypes: []ContentType{ApplicationYAML, ApplicationXYAML, TextYAML, TextXYAML},
+		DecodeFunc:   DecodeYAMLBody,
+	}
+)
+
+// DefaultDecoders are used by DecodeRequestBody if no decoders are passed.
+func DefaultDecoders() []Decoder {
+	return []Decoder{JSONDecoder, XMLDecoder, YAMLDecoder}
+}
+
+// DecodeRequestBody decodes the request body into v by the decoder of Content-Type of the request,
+// DefaultDecoders are used if none is given. If the request has no Content-Type, the first decoder is used.
+// The returned error wraps ErrUnsupportedMediaType if none of the decoders accepts the content type.
+func DecodeRequestBody(r *http.Request, v any, decoders ...Decoder) error {
+	if len(decoders) == 0 {
+		decoders = DefaultDecoders()
+	}
+	header := r.Header.Get(Header.ContentType)
+	if header == "" {
+		return decoders[0].DecodeFunc(r.Body, v)
+	}
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnsupportedMediaType, err)
+	}
+	for _, d := range decoders {
+		for _, c := range d.ContentTypes {
+			if string(c) == mediaType {
+				return d.DecodeFunc(r.Body, v)
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+}
+
+func negotiate(r *http.Request, encoders []Encoder, data any) (ContentType, Encoder, bool) {
+	if len(encoders) == 0 {
+		encoders = DefaultEncoders()
+	}
+	encoders = slices.DeleteFunc(slices.Clone(encoders), func(e Encoder) bool {
+		return e.CanEncode != nil && !e.CanEncode(data)
+	})
+	var offers []ContentType
+	for _, e := range encoders {
+		offers = append(offers, e.ContentTypes...)
+	}
+	contentType, ok := NegotiateContentType(r.Header.Get(Header.Accept), offers...)
+	if !ok {
+		return "", Encoder{}, false
+	}
+	for _, e := range encoders {
+		for _, c := range e.ContentTypes {
+			if c == contentType {
+				return contentType, e, true
+			}
+		}
+	}
+	return "", Encoder{}, false
+}
+
+// MediaRange is a media range of Accept header, e.g. text/* with quality 0.5.
+type MediaRange struct {
+	// Type and Subtype are lowercase, "*" matches any type or subtype.
+	Type    string
+	Subtype string
+	// Params are media type parameters except the quality.
+	Params map[string]string
+	// Quality is the relative weight from 0 to 1 (q parameter), 0 means not acceptable.
+	Quality float64
+}
+
+// Match reports whether the content type is in the media range. Parameters of the content type are ignored.
+func (m MediaRange) Match(c ContentType) bool {
+	return m.specificity(c) >= 0
+}
+
+// specificity returns how specifically the range matches the content type (higher is more specific),
+// or -1 if it does not match.
+func (m MediaRange) specificity(c ContentType) int {
+	mediaType, _, err := mime.ParseMediaType(string(c))
+	if err != nil {
+		return -1
+	}
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	if (m.Type == "*" || m.Type == typ) && (m.Subtype == "*" || m.Subtype == subtype) {
+		return m.rank()
+	}
+	return -1
+}
+
+// ParseAccept parses Accept header into media ranges sorted by quality and specificity (the most preferred first).
+// Invalid media ranges are skipped.
+func ParseAccept(header string) []MediaRange {
+	var ranges []MediaRange
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+		mr := MediaRange{Type: typ, Subtype: subtype, Quality: 1}
+		if q, ok := params["q"]; ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			if err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+			mr.Quality = quality
+			delete(params, "q")
+		}
+		if len(params) > 0 {
+			mr.Params = params
+		}
+		ranges = append(ranges, mr)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Quality != ranges[j].Quality {
+			return ranges[i].Quality > ranges[j].Quality
+		}
+		return ranges[i].rank() > ranges[j].rank()
+	})
+	return ranges
+}
+
+// rank orders ranges of the same quality, e.g. text/html;level=1 before text/html before text/* before */*.
+func (m MediaRange) rank() int {
+	switch {
+	case m.Type == "*":
+		return rankAnyType
+	case m.Subtype == "*":
+		return rankAnySubtype
+	default:
+		return rankSubtype + len(m.Params)
+	}
+}
+
+// NegotiateContentType returns the offered content type most preferred by Accept header. The quality of each offer
+// is taken from the most specific matching media range, offers with the same quality are preferred in the given order.
+// If the header is empty, the first offer is returned. False is returned if no offer is acceptable.
+func NegotiateContentType(accept string, offers ...ContentType) (ContentType, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+	ranges := ParseAccept(accept)
+	var (
+		best        ContentType
+		bestQuality float64
+	)
+	for _, offer := range offers {
+		quality, specificity := 0.0, -1
+		for _, mr := range ranges {
+			if s := mr.specificity(offer); s > specificity {
+				quality, specificity = mr.Quality, s
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best, bestQuality > 0
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net/internal"
+)
+
+func TestParseAccept(t *testing.T) {
+	got := ParseAccept("text/*;q=0.3, text/html;q=0.7, text/html;level=1, */*;q=0.5, invalid, application/json;q=2")
+	assert.Equal(t, []MediaRange{
+		{Type: "text", Subtype: "html", Params: map[string]string{"level": "1"}, Quality: 1},
+		{Type: "text", Subtype: "html", Quality: 0.7},
+		{Type: "*", Subtype: "*", Quality: 0.5},
+		{Type: "text", Subtype: "*", Quality: 0.3},
+	}, got)
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []ContentType{ApplicationJSON, ApplicationXML, TextYAML}
+	tests := []struct {
+		name   string
+		accept string
+		want   ContentType
+		wantOK bool
+	}{
+		{name: "success:empty", accept: "", want: ApplicationJSON, wantOK: true},
+		{name: "success:any", accept: "*/*", want: ApplicationJSON, wantOK: true},
+		{name: "success:exact", accept: "application/xml", want: ApplicationXML, wantOK: true},
+		{name: "success:case-insensitive", accept: "Text/YAML", want: TextYAML, wantOK: true},
+		{name: "success:quality", accept: "application/json;q=0.5, application/xml;q=0.9", want: ApplicationXML, wantOK: true},
+		{name: "success:subtype-wildcard", accept: "text/*, application/json;q=0.1", want: TextYAML, wantOK: true},
+		{name: "success:most-specific-range", accept: "application/*;q=0.9, application/json;q=0.1", want: ApplicationXML, wantOK: true},
+		{name: "failure:excluded", accept: "application/json;q=0, */*;q=0", wantOK: false},
+		{name: "failure:not-offered", accept: "image/png", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NegotiateContentType(tt.accept, offers...)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteResponse_WithNegotiation(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name" yaml:"name"`
+	}
+	tests := []struct {
+		name            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "success:json",
+			accept:          "application/json",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"name":"alice"}` + "\n",
+		},
+		{
+			name:            "success:xml",
+			accept:          "text/xml",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/xml; charset=utf-8",
+			wantBody:        `<?xml version="1.0" encoding="UTF-8"?>` + "\n<user><name>alice</name></user>",
+		},
+		{
+			name:            "success:yaml",
+			accept:          "application/yaml",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/yaml; charset=utf-8",
+			wantBody:        "name: alice\n",
+		},
+		{
+			name:            "failure:not-acceptable",
+			accept:          "image/png",
+			wantStatus:      http.StatusNotAcceptable,
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"errorCode":"ERR_NOT_ACCEPTABLE"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(Header.Accept, tt.accept)
+			w := httptest.NewRecorder()
+			rw := NewResponseWriter(w, internal.NewNopLogger())
+
+			require.NoError(t, WriteResponse(rw, user{Name: "alice"}, http.StatusOK, WithNegotiation(r)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get(Header.ContentType))
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, []string{Header.Accept}, w.Header().Values(Header.Vary))
+			if tt.wantStatus == http.StatusNotAcceptable {
+				assert.ErrorIs(t, rw.ErrorObject(), ErrNotAcceptable)
+			}
+		})
+	}
+}
+
+func TestWriteErrorResponse_WithErrorNegotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "success:xml",
+			accept:          "application/xml",
+			wantContentType: "application/xml; charset=utf-8",
+			wantBody: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				"<error><requestId>request-1</requestId><errorCode>ERR_NOT_FOUND</errorCode></error>",
+		},
+		{
+			name:            "success:yaml",
+			accept:          "text/yaml",
+			wantContentType: "text/yaml; charset=utf-8",
+			wantBody:        "requestId: request-1\nerrorCode: ERR_NOT_FOUND\n",
+		},
+		{
+			name:            "success:not-acceptable-fallback",
+			accept:          "image/png",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"requestId":"request-1","errorCode":"ERR_NOT_FOUND"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(Header.Accept, tt.accept)
+			w := httptest.NewRecorder()
+
+			require.NoError(t, WriteErrorResponse(
+				w,
+				http.StatusNotFound,
+				WithRequestID("request-1"),
+				WithErrorCode("ERR_NOT_FOUND"),
+				WithErrorNegotiation(r),
+			))
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, tt.wantContentType, w.Header().Get(Header.ContentType))
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, []string{Header.Accept}, w.Header().Values(Header.Vary))
+		})
+	}
+}
+
+func TestYAML_JSONTags(t *testing.T) {
+	type user struct {
+		FirstName string `json:"firstName"`
+		Active    string `json:"active"`
+		Roles     []string
+	}
+	in := user{FirstName: "Alice", Active: "true", Roles: []string{"admin"}}
+	want := "firstName: Alice\nactive: \"true\"\nRoles:\n    - admin\n"
+
+	w := httptest.NewRecorder()
+	require.NoError(t, EncodeYAML(w, in))
+	assert.Equal(t, want, w.Body.String())
+
+	var out user
+	require.NoError(t, DecodeYAMLBody(strings.NewReader(want), &out))
+	assert.Equal(t, in, out)
+}
+
+func TestDecodeRequestBody(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name" yaml:"name"`
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        user
+		wantErr     error
+	}{
+		{
+			name:        "success:json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name":"alice"}`,
+			want:        user{Name: "alice"},
+		},
+		{
+			name:        "success:xml",
+			contentType: "text/xml",
+			body:        "<user><name>alice</name></user>",
+			want:        user{Name: "alice"},
+		},
+		{
+			name:        "success:yaml",
+			contentType: "application/x-yaml",
+			body:        "name: alice\n",
+			want:        user{Name: "alice"},
+		},
+		{
+			name: "success:no-content-type",
+			body: `{"name":"alice"}`,
+			want: user{Name: "alice"},
+		},
+		{
+			name:        "failure:unsupported",
+			contentType: "image/png",
+			body:        "png",
+			wantErr:     ErrUnsupportedMediaType,
+		},
+		{
+			name:        "failure:invalid",
+			contentType: "application/",
+			body:        "",
+			wantErr:     ErrUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set(Header.ContentType, tt.contentType)
+			}
+
+			var got user
+			err := DecodeRequestBody(r, &got)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -15,6 +15,23 @@ const (
 	pathTagValuePrefix  = "path"
 )
 
+// ParseError is returned by Parser.Parse if a path or query parameter cannot be unmarshaled into the tagged field.
+type ParseError struct {
+	// In is the location of the parameter, "path" or "query".
+	In string
+	// Name is the name of the parameter.
+	Name string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("unmarshaling %s parameter %s: %v", e.In, e.Name, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 // TagResolver is a function that decides from a field tag what parameter should be searched.
 // Second return value should return whether the parameter should be searched at all.
 type TagResolver func(fieldTag reflect.StructTag) (string, bool)
@@ -211,7 +228,7 @@ func (p Parser) parsePathParam(r *http.Request, paramName string, v reflect.Valu
 	if paramValue != "" {
 		err := unmarshalValue(paramValue, v)
 		if err != nil {
-			return fmt.Errorf("unmarshaling path parameter %s: %w", paramName, err)
+			return &ParseError{In: pathTagValuePrefix, Name: paramName, Err: err}
 		}
 	}
 	return nil
@@ -222,7 +239,7 @@ func (p Parser) parseQueryParam(r *http.Request, paramName string, v reflect.Val
 	if values, ok := query[paramName]; ok && len(values) > 0 {
 		err := unmarshalValueOrSlice(values, v)
 		if err != nil {
-			return fmt.Errorf("unmarshaling query parameter %s: %w", paramName, err)
+			return &ParseError{In: queryTagValuePrefix, Name: paramName, Err: err}
 		}
 	}
 	return nil
@@ -373,6 +373,9 @@ func TestParser_Parse_QueryParam_CannotBeParsed(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, tc.query, nil)
 			err := parser.Parse(req, tc.resultStruct)
 			assert.ErrorIs(t, err, tc.errorTarget)
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			assert.Equal(t, "query", parseErr.In)
 		})
 	}
 }
@@ -0,0 +1,261 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+)
+
+// ProblemTypeBlank is the default problem type, meaning the problem has no additional semantics
+// beyond the HTTP status code.
+const ProblemTypeBlank = "about:blank"
+
+// Members of Problem Details, see RFC 9457.
+const (
+	problemTypeMember      = "type"
+	problemTitleMember     = "title"
+	problemStatusMember    = "status"
+	problemDetailMember    = "detail"
+	problemInstanceMember  = "instance"
+	problemStandardMembers = 5
+
+	problemErrorCodeMember = "errorCode"
+	problemErrorDataMember = "errorData"
+	problemRequestIDMember = "requestId"
+	problemTraceIDMember   = "traceId"
+)
+
+// ErrNoProblemDetails is returned by ParseProblem if the response does not contain an error response.
+var ErrNoProblemDetails = errors.New("response does not contain problem details")
+
+// ProblemJSONEncoder encodes error responses as Problem Details, see WithProblemNegotiation.
+var ProblemJSONEncoder = Encoder{ContentTypes: []ContentType{ApplicationProblemJSON}, EncodeFunc: EncodeJSON}
+
+// Problem is an error response in the format of Problem Details for HTTP APIs (RFC 9457).
+//
+// Extensions contain additional members of the problem. Error responses written by WriteErrorResponse
+// contain errorCode, and requestId, traceId and errorData if set.
+type Problem struct {
+	// Type is a URI reference identifying the problem type, ProblemTypeBlank if empty.
+	Type string
+	// Title is a short summary of the problem type, the status text for ProblemTypeBlank.
+	Title string
+	// Status is the HTTP status code.
+	Status int
+	// Detail is an explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference identifying this occurrence of the problem.
+	Instance   string
+	Extensions map[string]any
+}
+
+// Error returns the title and the detail of the problem, so the problem can be returned by clients as an error.
+func (p Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return fmt.Sprintf("%s: %s", p.Title, p.Detail)
+}
+
+// ErrorCode returns errorCode extension member, empty string if missing.
+func (p Problem) ErrorCode() string {
+	return p.stringExtension(problemErrorCodeMember)
+}
+
+// RequestID returns requestId extension member, empty string if missing.
+func (p Problem) RequestID() string {
+	return p.stringExtension(problemRequestIDMember)
+}
+
+func (p Problem) stringExtension(member string) string {
+	s, _ := p.Extensions[member].(string)
+	return s
+}
+
+// MarshalJSON encodes the problem as a JSON object with extension members next to the standard ones.
+// Extension members named as standard members are ignored.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+problemStandardMembers)
+	maps.Copy(m, p.Extensions)
+	m[problemTypeMember] = p.Type
+	if p.Type == "" {
+		m[problemTypeMember] = ProblemTypeBlank
+	}
+	setNonEmpty(m, problemTitleMember, p.Title)
+	if p.Status != 0 {
+		m[problemStatusMember] = p.Status
+	}
+	setNonEmpty(m, problemDetailMember, p.Detail)
+	setNonEmpty(m, problemInstanceMember, p.Instance)
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes standard members of the problem, other members are decoded into Extensions.
+// Standard members of an unexpected type are ignored, as required by RFC 9457.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*p = Problem{}
+	for member, raw := range members {
+		switch member {
+		case problemTypeMember:
+			_ = json.Unmarshal(raw, &p.Type)
+		case problemTitleMember:
+			_ = json.Unmarshal(raw, &p.Title)
+		case problemStatusMember:
+			_ = json.Unmarshal(raw, &p.Status)
+		case problemDetailMember:
+			_ = json.Unmarshal(raw, &p.Detail)
+		case problemInstanceMember:
+			_ = json.Unmarshal(raw, &p.Instance)
+		default:
+			var v any
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return err
+			}
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]any)
+			}
+			p.Extensions[member] = v
+		}
+	}
+	if p.Type == "" {
+		p.Type = ProblemTypeBlank
+	}
+	return nil
+}
+
+func setNonEmpty(m map[string]any, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
+
+// WithProblemDetails writes the error response as application/problem+json (RFC 9457) instead of
+// the default format. ErrMessage is written as detail, ErrCode, ErrData, RequestID and TraceID
+// as errorCode, errorData, requestId and traceId extension members.
+func WithProblemDetails() ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.problem = true
+		o.ContentType = ApplicationProblemJSON
+		o.EncodeFunc = EncodeJSON
+	}
+}
+
+// WithProblemNegotiation selects the format of the error response according to Accept header of the request:
+// Problem Details (see WithProblemDetails) if application/problem+json is preferred, the default format
+// encoded by one of the encoders otherwise (see WithErrorNegotiation). The encoders are preferred
+// if the client accepts both formats equally, e.g. */*. Vary header is extended by Accept, see WithNegotiation.
+func WithProblemNegotiation(r *http.Request, encoders ...Encoder) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.varyAccept = true
+		if len(encoders) == 0 {
+			encoders = DefaultEncoders()
+		}
+		contentType, encoder, ok := negotiate(r, append(encoders[:len(encoders):len(encoders)], ProblemJSONEncoder), ErrorResponseOptions{})
+		if !ok {
+			return
+		}
+		o.problem = contentType == ApplicationProblemJSON
+		o.ContentType = contentType
+		o.EncodeFunc = encoder.EncodeFunc
+		if encoder.Binary {
+			o.CharsetType = ""
+		}
+	}
+}
+
+// WithProblemType sets the type and the title of Problem Details error response, see WithProblemDetails.
+func WithProblemType(typeURI, title string) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.problemDetails.Type = typeURI
+		o.problemDetails.Title = title
+	}
+}
+
+// WithProblemInstance sets the instance of Problem Details error response, e.g. the path of the request.
+func WithProblemInstance(instance string) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.problemDetails.Instance = instance
+	}
+}
+
+// WithProblemExtension adds an extension member to Problem Details error response.
+func WithProblemExtension(member string, value any) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		if o.problemDetails.Extensions == nil {
+			o.problemDetails.Extensions = make(map[string]any)
+		}
+		o.problemDetails.Extensions[member] = value
+	}
+}
+
+// toProblem maps the error response to Problem Details.
+func (o *ErrorResponseOptions) toProblem(statusCode int) Problem {
+	p := o.problemDetails
+	p.Extensions = maps.Clone(p.Extensions)
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	if p.Type == "" {
+		p.Type = ProblemTypeBlank
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(statusCode)
+	}
+	p.Status = statusCode
+	p.Detail = o.ErrMessage
+	p.Extensions[problemErrorCodeMember] = o.ErrCode
+	if o.RequestID != "" {
+		p.Extensions[problemRequestIDMember] = o.RequestID
+	}
+	if o.TraceID != "" {
+		p.Extensions[problemTraceIDMember] = o.TraceID
+	}
+	if o.ErrData != nil {
+		p.Extensions[problemErrorDataMember] = o.ErrData
+	}
+	return p
+}
+
+// ParseProblem parses the error response of an API into Problem. Both Problem Details (application/problem+json)
+// and the default format of WriteErrorResponse (application/json) are supported, the latter is mapped
+// the same way as by WithProblemDetails. The body of the response is read, but not closed.
+// ErrNoProblemDetails is returned if the response has another content type.
+func ParseProblem(resp *http.Response) (*Problem, error) {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get(Header.ContentType))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoProblemDetails, err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ContentType(mediaType) {
+	case ApplicationProblemJSON:
+		p := &Problem{}
+		if err := json.Unmarshal(body, p); err != nil {
+			return nil, fmt.Errorf("decoding problem details: %w", err)
+		}
+		if p.Status == 0 {
+			p.Status = resp.StatusCode
+		}
+		return p, nil
+	case ApplicationJSON:
+		var o ErrorResponseOptions
+		if err := json.Unmarshal(body, &o); err != nil {
+			return nil, fmt.Errorf("decoding error response: %w", err)
+		}
+		p := o.toProblem(resp.StatusCode)
+		return &p, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrNoProblemDetails, mediaType)
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrorResponse_WithProblemDetails(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ErrorResponseOption
+		wantBody map[string]any
+	}{
+		{
+			name: "success:mapped",
+			opts: []ErrorResponseOption{
+				WithErrorCode("ERR_NOT_FOUND"),
+				WithErrorMessage("user not found"),
+				WithErrorData(map[string]any{"id": "1"}),
+				WithRequestID("request-1"),
+			},
+			wantBody: map[string]any{
+				"type":      "about:blank",
+				"title":     "Not Found",
+				"status":    float64(http.StatusNotFound),
+				"detail":    "user not found",
+				"errorCode": "ERR_NOT_FOUND",
+				"errorData": map[string]any{"id": "1"},
+				"requestId": "request-1",
+			},
+		},
+		{
+			name: "success:type-instance-extension",
+			opts: []ErrorResponseOption{
+				WithErrorCode("ERR_NOT_FOUND"),
+				WithProblemType("https://example.com/problems/user-not-found", "User not found"),
+				WithProblemInstance("/users/1"),
+				WithProblemExtension("userId", "1"),
+			},
+			wantBody: map[string]any{
+				"type":      "https://example.com/problems/user-not-found",
+				"title":     "User not found",
+				"status":    float64(http.StatusNotFound),
+				"instance":  "/users/1",
+				"errorCode": "ERR_NOT_FOUND",
+				"userId":    "1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			opts := append([]ErrorResponseOption{WithProblemDetails()}, tt.opts...)
+			require.NoError(t, WriteErrorResponse(w, http.StatusNotFound, opts...))
+
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Equal(t, "application/problem+json; charset=utf-8", w.Header().Get(Header.ContentType))
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestWriteErrorResponse_WithProblemNegotiation(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "success:problem",
+			accept:          "application/problem+json",
+			wantContentType: "application/problem+json; charset=utf-8",
+			wantBody:        `{"errorCode":"ERR_NOT_FOUND","status":404,"title":"Not Found","type":"about:blank"}` + "\n",
+		},
+		{
+			name:            "success:default-format",
+			accept:          "application/json",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"errorCode":"ERR_NOT_FOUND"}` + "\n",
+		},
+		{
+			name:            "success:any",
+			accept:          "*/*",
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `{"errorCode":"ERR_NOT_FOUND"}` + "\n",
+		},
+		{
+			name:            "success:problem-preferred",
+			accept:          "application/json;q=0.5, application/problem+json",
+			wantContentType: "application/problem+json; charset=utf-8",
+			wantBody:        `{"errorCode":"ERR_NOT_FOUND","status":404,"title":"Not Found","type":"about:blank"}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(Header.Accept, tt.accept)
+			w := httptest.NewRecorder()
+
+			require.NoError(t, WriteErrorResponse(w, http.StatusNotFound, WithErrorCode("ERR_NOT_FOUND"), WithProblemNegotiation(r)))
+
+			assert.Equal(t, tt.wantContentType, w.Header().Get(Header.ContentType))
+			assert.Equal(t, tt.wantBody, w.Body.String())
+			assert.Equal(t, []string{Header.Accept}, w.Header().Values(Header.Vary))
+		})
+	}
+}
+
+func TestParseProblem(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        *Problem
+		wantErr     error
+	}{
+		{
+			name:        "success:problem",
+			contentType: "application/problem+json",
+			body:        `{"type":"https://example.com/problems/out-of-credit","title":"Out of credit","status":"invalid","detail":"Balance is 30.","balance":30,"errorCode":"ERR_CREDIT"}`,
+			want: &Problem{
+				Type:       "https://example.com/problems/out-of-credit",
+				Title:      "Out of credit",
+				Status:     http.StatusForbidden,
+				Detail:     "Balance is 30.",
+				Extensions: map[string]any{"balance": float64(30), "errorCode": "ERR_CREDIT"},
+			},
+		},
+		{
+			name:        "success:default-format",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"requestId":"request-1","errorCode":"ERR_CREDIT","errorMessage":"Balance is 30."}`,
+			want: &Problem{
+				Type:       "about:blank",
+				Title:      "Forbidden",
+				Status:     http.StatusForbidden,
+				Detail:     "Balance is 30.",
+				Extensions: map[string]any{"requestId": "request-1", "errorCode": "ERR_CREDIT"},
+			},
+		},
+		{
+			name:        "failure:content-type",
+			contentType: "text/html",
+			body:        "<html></html>",
+			wantErr:     ErrNoProblemDetails,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: http.StatusForbidden,
+				Header:     http.Header{Header.ContentType: []string{tt.contentType}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+
+			p, err := ParseProblem(resp)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+			assert.Equal(t, "ERR_CREDIT", p.ErrorCode())
+		})
+	}
+}
+
+func TestParseProblem_RoundTrip(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, WriteErrorResponse(
+		w,
+		http.StatusConflict,
+		WithProblemDetails(),
+		WithErrorCode("ERR_CONFLICT"),
+		WithErrorMessage("already exists"),
+		WithRequestID("request-1"),
+	))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	p, err := ParseProblem(resp)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, p.Status)
+	assert.Equal(t, "ERR_CONFLICT", p.ErrorCode())
+	assert.Equal(t, "request-1", p.RequestID())
+	assert.EqualError(t, p, "Conflict: already exists")
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"go.strv.io/net"
+)
+
+// ForwardedHeader selects a header RealIPMiddleware resolves the client from.
+type ForwardedHeader int
+
+const (
+	// ForwardedRFC7239 is the standardized Forwarded header (RFC 7239).
+	ForwardedRFC7239 ForwardedHeader = iota
+	// ForwardedXForwardedFor is the X-Forwarded-For header, with X-Forwarded-Proto and X-Forwarded-Host.
+	ForwardedXForwardedFor
+	// ForwardedXRealIP is the X-Real-Ip header, containing only the client address.
+	ForwardedXRealIP
+)
+
+type RealIPMiddlewareOptions struct {
+	header ForwardedHeader
+}
+
+type RealIPMiddlewareOption func(*RealIPMiddlewareOptions)
+
+// WithForwardedHeader sets the header that is used for resolving the client. Default is X-Forwarded-For.
+// It has to be the header that the trusted proxies always overwrite or append to, other forwarding headers
+// are ignored, as they can be sent by the client to spoof its address.
+func WithForwardedHeader(header ForwardedHeader) RealIPMiddlewareOption {
+	return func(o *RealIPMiddlewareOptions) {
+		o.header = header
+	}
+}
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges (e.g. "10.0.0.0/8") of trusted proxies.
+func ParseTrustedProxies(proxies ...string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, fmt.Errorf("parsing trusted proxy %q: %w", p, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("parsing trusted proxy %q: %w", p, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// RealIPMiddleware resolves the original client of the request and saves it into the context (see net.ClientFromCtx).
+//
+// The forwarding header (see WithForwardedHeader) is considered only if the request comes from a trusted proxy. The chain of addresses
+// in the header is walked from right to left, skipping trusted proxies, and the first untrusted address
+// is the client. Scheme and host are taken from the same hop of the Forwarded header, or from X-Forwarded-Proto
+// and X-Forwarded-Host headers. If the request does not come from a trusted proxy, RemoteAddr, TLS state and Host
+// of the request are used.
+func RealIPMiddleware(trustedProxies []netip.Prefix, opts ...RealIPMiddlewareOption) func(http.Handler) http.Handler {
+	options := RealIPMiddlewareOptions{
+		header: ForwardedXForwardedFor,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	trusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+		for _, p := range trustedProxies {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := resolveClient(r, options.header, trusted)
+			ctx := net.WithClient(r.Context(), client)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// forwardedHop is a single proxy hop from a forwarding header.
+type forwardedHop struct {
+	addr  netip.Addr
+	proto string
+	host  string
+}
+
+func resolveClient(r *http.Request, header ForwardedHeader, trusted func(netip.Addr) bool) net.Client {
+	client := net.Client{
+		IP:     parseHostAddr(r.RemoteAddr),
+		Scheme: "http",
+		Host:   r.Host,
+	}
+	if r.TLS != nil {
+		client.Scheme = "https"
+	}
+	if !client.IP.IsValid() || !trusted(client.IP) {
+		return client
+	}
+
+	hops := forwardedHops(r.Header, header)
+
+	// Walk from the closest proxy to the client and stop at the first untrusted (or unparseable) address.
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		if !hop.addr.IsValid() {
+			break
+		}
+		client.IP = hop.addr
+		if hop.proto != "" {
+			client.Scheme = hop.proto
+		}
+		if hop.host != "" {
+			client.Host = hop.host
+		}
+		if !trusted(hop.addr) {
+			break
+		}
+	}
+	return client
+}
+
+func forwardedHops(h http.Header, header ForwardedHeader) []forwardedHop {
+	switch header {
+	case ForwardedRFC7239:
+		return parseForwarded(h.Values(Header.Forwarded))
+	case ForwardedXForwardedFor:
+		addrs := splitHeaderList(h.Values(Header.XForwardedFor))
+		protos := splitHeaderList(h.Values(Header.XForwardedProto))
+		hosts := splitHeaderList(h.Values(Header.XForwardedHost))
+		hops := make([]forwardedHop, 0, len(addrs))
+		for i, a := range addrs {
+			hops = append(hops, forwardedHop{
+				addr:  parseHostAddr(a),
+				proto: correspondingValue(protos, i, len(addrs)),
+				host:  correspondingValue(hosts, i, len(addrs)),
+			})
+		}
+		return hops
+	case ForwardedXRealIP:
+		if v := h.Get(Header.XRealIP); v != "" {
+			return []forwardedHop{{addr: parseHostAddr(strings.TrimSpace(v))}}
+		}
+	}
+	return nil
+}
+
+// parseForwarded parses RFC 7239 Forwarded header values, e.g. `for=192.0.2.60;proto=https, for="[2001:db8::1]:4711"`.
+func parseForwarded(values []string) []forwardedHop {
+	var hops []forwardedHop
+	for _, element := range splitHeaderList(values) {
+		hop := forwardedHop{}
+		for _, pair := range strings.Split(element, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				continue
+			}
+			v = strings.Trim(v, `"`)
+			switch strings.ToLower(k) {
+			case "for":
+				hop.addr = parseHostAddr(v)
+			case "proto":
+				hop.proto = strings.ToLower(v)
+			case "host":
+				hop.host = v
+			}
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+// parseHostAddr parses an address with optional port, e.g. "192.0.2.1", "192.0.2.1:80", "[2001:db8::1]:80".
+// It returns an invalid address for obfuscated identifiers like "unknown" or "_hidden".
+func parseHostAddr(s string) netip.Addr {
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap()
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func splitHeaderList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// correspondingValue returns the value for i-th address, if there are as many values as addresses.
+// Otherwise, the last value is returned, as it is set by the nearest proxy, while the leading values
+// can be sent by the client.
+func correspondingValue(values []string, i int, n int) string {
+	switch {
+	case len(values) == 0:
+		return ""
+	case len(values) == n:
+		return values[i]
+	default:
+		return values[len(values)-1]
+	}
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	trusted, err := ParseTrustedProxies("10.0.0.0/8", "2001:db8:ffff::/48", "192.168.1.1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		tls        bool
+		header     http.Header
+		opts       []RealIPMiddlewareOption
+		want       net.Client
+	}{
+		{
+			name:       "success:untrusted-remote-ignores-headers",
+			remoteAddr: "203.0.113.7:1234",
+			header:     http.Header{Header.XForwardedFor: {"198.51.100.1"}},
+			want:       net.Client{IP: netip.MustParseAddr("203.0.113.7"), Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:       "success:x-forwarded-for",
+			remoteAddr: "10.0.0.2:1234",
+			header: http.Header{
+				Header.XForwardedFor:   {"198.51.100.99, 203.0.113.7", "10.0.0.1"},
+				Header.XForwardedProto: {"https"},
+				Header.XForwardedHost:  {"www.example.com"},
+			},
+			want: net.Client{IP: netip.MustParseAddr("203.0.113.7"), Scheme: "https", Host: "www.example.com"},
+		},
+		{
+			name:       "success:spoofed-x-forwarded-proto-ignored",
+			remoteAddr: "10.0.0.2:1234",
+			header: http.Header{
+				Header.XForwardedFor:   {"203.0.113.7, 10.0.0.1"},
+				Header.XForwardedProto: {"ftp, ws, https"},
+				Header.XForwardedHost:  {"evil.example.com, evil.example.org, www.example.com"},
+			},
+			want: net.Client{IP: netip.MustParseAddr("203.0.113.7"), Scheme: "https", Host: "www.example.com"},
+		},
+		{
+			name:       "success:all-trusted",
+			remoteAddr: "10.0.0.2:1234",
+			header:     http.Header{Header.XForwardedFor: {"10.0.0.5, 10.0.0.1"}},
+			want:       net.Client{IP: netip.MustParseAddr("10.0.0.5"), Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:       "success:forwarded",
+			remoteAddr: "[2001:db8:ffff::1]:443",
+			tls:        true,
+			header: http.Header{
+				Header.Forwarded:     {`for="[2001:db8:cafe::17]:4711";proto=http;host=example.com, for=192.168.1.1;proto=https`},
+				Header.XForwardedFor: {"198.51.100.1"},
+			},
+			opts: []RealIPMiddlewareOption{WithForwardedHeader(ForwardedRFC7239)},
+			want: net.Client{IP: netip.MustParseAddr("2001:db8:cafe::17"), Scheme: "http", Host: "example.com"},
+		},
+		{
+			name:       "success:forwarded-obfuscated",
+			remoteAddr: "10.0.0.2:1234",
+			header:     http.Header{Header.Forwarded: {"for=unknown, for=10.0.0.1"}},
+			opts:       []RealIPMiddlewareOption{WithForwardedHeader(ForwardedRFC7239)},
+			want:       net.Client{IP: netip.MustParseAddr("10.0.0.1"), Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:       "success:x-real-ip",
+			remoteAddr: "192.168.1.1:1234",
+			header:     http.Header{Header.XRealIP: {"203.0.113.7"}},
+			opts:       []RealIPMiddlewareOption{WithForwardedHeader(ForwardedXRealIP)},
+			want:       net.Client{IP: netip.MustParseAddr("203.0.113.7"), Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:       "success:other-header-ignored",
+			remoteAddr: "192.168.1.1:1234",
+			tls:        true,
+			header:     http.Header{Header.XRealIP: {"203.0.113.7"}},
+			want:       net.Client{IP: netip.MustParseAddr("192.168.1.1"), Scheme: "https", Host: "api.example.com"},
+		},
+		{
+			name:       "success:spoofed-forwarded-ignored",
+			remoteAddr: "10.0.0.2:1234",
+			header: http.Header{
+				Header.Forwarded:     {"for=6.6.6.6"},
+				Header.XForwardedFor: {"203.0.113.7"},
+			},
+			want: net.Client{IP: netip.MustParseAddr("203.0.113.7"), Scheme: "http", Host: "api.example.com"},
+		},
+		{
+			name:       "success:spoofed-x-forwarded-for-ignored",
+			remoteAddr: "10.0.0.2:1234",
+			header: http.Header{
+				Header.Forwarded:     {"for=203.0.113.7"},
+				Header.XForwardedFor: {"6.6.6.6"},
+			},
+			opts: []RealIPMiddlewareOption{WithForwardedHeader(ForwardedRFC7239)},
+			want: net.Client{IP: netip.MustParseAddr("203.0.113.7"), Scheme: "http", Host: "api.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got net.Client
+			h := RealIPMiddleware(trusted, tt.opts...)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got, _ = net.ClientFromCtx(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "http://api.example.com/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header = tt.header
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,14 +1,23 @@
 package http
 
 import (
+	"encoding/xml"
 	"fmt"
 	"net/http"
+
+	"golang.org/x/text/language"
 )
 
 type ResponseOptions struct {
 	EncodeFunc  EncodeFunc
 	ContentType ContentType
 	CharsetType CharsetType
+
+	notAcceptable bool
+	// varyAccept adds Accept to Vary header, as the response depends on the negotiated content type.
+	varyAccept bool
+	// negotiate selects the encoder once the data is known, see WithNegotiation.
+	negotiate func(o *ResponseOptions, data any)
 }
 
 type ResponseOption func(*ResponseOptions)
@@ -23,6 +32,20 @@ func WriteResponse(
 	for _, opt := range opts {
 		opt(&o)
 	}
+	if o.negotiate != nil {
+		o.negotiate(&o, data)
+	}
+	if o.varyAccept {
+		w.Header().Add(Header.Vary, Header.Accept)
+	}
+	if o.notAcceptable {
+		return WriteErrorResponse(
+			w,
+			http.StatusNotAcceptable,
+			WithError(ErrNotAcceptable),
+			WithErrorCode(errCodeNotAcceptable),
+		)
+	}
 
 	w.Header().Set(
 		Header.ContentType,
@@ -62,6 +85,20 @@ func WriteErrorResponse(
 	for _, opt := range opts {
 		opt(&o)
 	}
+	if o.TraceID == "" {
+		o.TraceID = traceIDFromHeader(w.Header())
+	}
+	if o.catalog != nil {
+		if msg, ok := o.catalog.Message(o.language, o.ErrCode); ok {
+			o.ErrMessage = msg
+		}
+	}
+	for _, f := range o.finalizers {
+		f(&o)
+	}
+	if o.varyAccept {
+		w.Header().Add(Header.Vary, Header.Accept)
+	}
 
 	w.Header().Set(
 		Header.ContentType,
@@ -69,7 +106,7 @@ func WriteErrorResponse(
 	)
 	w.WriteHeader(statusCode)
 
-	if rw, ok := w.(*ResponseWriter); ok {
+	if rw, ok := UnwrapResponseWriter(w); ok {
 		rw.SetErrorObject(o.Err)
 	}
 
@@ -77,7 +114,11 @@ func WriteErrorResponse(
 		return nil
 	}
 
-	if err := o.EncodeFunc(w, o); err != nil {
+	var data any = o
+	if o.problem {
+		data = o.toProblem(statusCode)
+	}
+	if err := o.EncodeFunc(w, data); err != nil {
 		return fmt.Errorf("response encoding: %w", err)
 	}
 
@@ -85,14 +126,26 @@ func WriteErrorResponse(
 }
 
 type ErrorResponseOptions struct {
-	ResponseOptions `json:"-"`
+	ResponseOptions `json:"-" xml:"-" yaml:"-"`
+	XMLName         xml.Name `json:"-" xml:"error" yaml:"-"`
+
+	RequestID string `json:"requestId,omitempty" xml:"requestId,omitempty" yaml:"requestId,omitempty"`
+	TraceID   string `json:"traceId,omitempty" xml:"traceId,omitempty" yaml:"traceId,omitempty"`
+
+	Err        error  `json:"-" xml:"-" yaml:"-"`
+	ErrCode    string `json:"errorCode" xml:"errorCode" yaml:"errorCode"`
+	ErrMessage string `json:"errorMessage,omitempty" xml:"errorMessage,omitempty" yaml:"errorMessage,omitempty"`
+	ErrData    any    `json:"errorData,omitempty" xml:"errorData,omitempty" yaml:"errorData,omitempty"`
+
+	catalog  MessageCatalog
+	language language.Tag
 
-	RequestID string `json:"requestId,omitempty"`
+	problem        bool
+	problemDetails Problem
 
-	Err        error  `json:"-"`
-	ErrCode    string `json:"errorCode"`
-	ErrMessage string `json:"errorMessage,omitempty"`
-	ErrData    any    `json:"errorData,omitempty"`
+	// finalizers adjust the response after all options are applied and the message is translated,
+	// see ErrorWriter.
+	finalizers []ErrorResponseOption
 }
 
 type ErrorResponseOption func(*ErrorResponseOptions)
@@ -103,6 +156,14 @@ func WithRequestID(id string) ErrorResponseOption {
 	}
 }
 
+// WithTraceID sets the trace ID of the response. If not set, it is taken from traceparent header
+// of the response, set by TraceContextMiddleware.
+func WithTraceID(id string) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.TraceID = id
+	}
+}
+
 func WithError(err error) ErrorResponseOption {
 	return func(o *ErrorResponseOptions) {
 		o.Err = err
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
@@ -17,6 +18,10 @@ type ResponseWriter struct {
 	logger            *slog.Logger
 	err               error
 	panic             any
+	tees              []io.Writer
+	logAttrs          []slog.Attr
+	requestLogAttrs   []slog.Attr
+	bytesWritten      int64
 }
 
 func NewResponseWriter(w http.ResponseWriter, l *slog.Logger) *ResponseWriter {
@@ -44,6 +49,29 @@ func (r *ResponseWriter) WriteHeader(statusCode int) {
 	).WarnContext(context.TODO(), "WriteHeader multiple call")
 }
 
+// Write writes the data to the underlying writer and copies it to all writers registered by TeeBody.
+// If WriteHeader has not been called yet, http.StatusOK is written first.
+func (r *ResponseWriter) Write(b []byte) (int, error) {
+	r.TryWriteHeader(http.StatusOK)
+	n, err := r.ResponseWriter.Write(b)
+	r.bytesWritten += int64(n)
+	for _, t := range r.tees {
+		_, _ = t.Write(b[:n])
+	}
+	return n, err
+}
+
+// BytesWritten returns the number of bytes of the response body written so far.
+func (r *ResponseWriter) BytesWritten() int64 {
+	return r.bytesWritten
+}
+
+// TeeBody registers a writer that receives a copy of every byte of the response body written from now on.
+// It can be used by middlewares that need to capture the response (e.g. for caching).
+func (r *ResponseWriter) TeeBody(w io.Writer) {
+	r.tees = append(r.tees, w)
+}
+
 func (r *ResponseWriter) ErrorObject() error {
 	return r.err
 }
@@ -60,6 +88,33 @@ func (r *ResponseWriter) SetPanicObject(p any) {
 	r.panic = p
 }
 
+// LogAttrs returns attributes added by AddLogAttrs.
+func (r *ResponseWriter) LogAttrs() []slog.Attr {
+	return r.logAttrs
+}
+
+// AddLogAttrs adds attributes that are logged by LoggingMiddleware together with the request.
+// It allows inner middlewares and handlers to enrich the request log (e.g. with a cache status).
+func (r *ResponseWriter) AddLogAttrs(attrs ...slog.Attr) {
+	r.logAttrs = append(r.logAttrs, attrs...)
+}
+
+// RequestLogAttrs returns attributes added by AddRequestLogAttrs.
+func (r *ResponseWriter) RequestLogAttrs() []slog.Attr {
+	return r.requestLogAttrs
+}
+
+// AddRequestLogAttrs adds attributes that are logged by LoggingMiddleware in the request group
+// (see RequestData), e.g. captured request and response bodies.
+func (r *ResponseWriter) AddRequestLogAttrs(attrs ...slog.Attr) {
+	r.requestLogAttrs = append(r.requestLogAttrs, attrs...)
+}
+
+// HeaderWritten reports whether the response header has been already written.
+func (r *ResponseWriter) HeaderWritten() bool {
+	return atomic.LoadInt32(&r.calledWriteHeader) == 1
+}
+
 func (r *ResponseWriter) TryWriteHeader(statusCode int) bool {
 	if atomic.CompareAndSwapInt32(&r.calledWriteHeader, 0, 1) {
 		r.ResponseWriter.WriteHeader(statusCode)
@@ -76,3 +131,24 @@ func (r *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	}
 	return h.Hijack()
 }
+
+// Unwrap returns the underlying http.ResponseWriter, so http.ResponseController can access its methods.
+func (r *ResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// UnwrapResponseWriter returns the first *ResponseWriter in the chain of wrapped writers.
+// Writers wrapping another writer are expected to have an Unwrap() http.ResponseWriter method,
+// the same way as required by http.ResponseController.
+func UnwrapResponseWriter(w http.ResponseWriter) (*ResponseWriter, bool) {
+	for {
+		switch t := w.(type) {
+		case *ResponseWriter:
+			return t, true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
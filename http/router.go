@@ -0,0 +1,131 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"go.strv.io/net"
+)
+
+const (
+	errCodeNotFound         = "ERR_NOT_FOUND"
+	errCodeMethodNotAllowed = "ERR_METHOD_NOT_ALLOWED"
+)
+
+type DefaultMiddlewareOptions struct {
+	requestIDFunc    RequestIDFunc
+	requestIDOptions []RequestIDMiddlewareOption
+	loggingOptions   []LoggingMiddlewareOption
+	recoverOptions   []RecoverMiddlewareOption
+}
+
+type DefaultMiddlewareOption func(*DefaultMiddlewareOptions)
+
+// WithRequestIDFunc sets the function obtaining the incoming request ID. By default, the ID is read
+// from the header set by WithRequestIDHeader.
+func WithRequestIDFunc(f RequestIDFunc) DefaultMiddlewareOption {
+	return func(o *DefaultMiddlewareOptions) {
+		o.requestIDFunc = f
+	}
+}
+
+// WithRequestIDOptions sets options of RequestIDMiddleware.
+func WithRequestIDOptions(opts ...RequestIDMiddlewareOption) DefaultMiddlewareOption {
+	return func(o *DefaultMiddlewareOptions) {
+		o.requestIDOptions = append(o.requestIDOptions, opts...)
+	}
+}
+
+// WithLoggingOptions sets options of LoggingMiddleware.
+func WithLoggingOptions(opts ...LoggingMiddlewareOption) DefaultMiddlewareOption {
+	return func(o *DefaultMiddlewareOptions) {
+		o.loggingOptions = append(o.loggingOptions, opts...)
+	}
+}
+
+// WithRecoverOptions sets options of RecoverMiddleware.
+func WithRecoverOptions(opts ...RecoverMiddlewareOption) DefaultMiddlewareOption {
+	return func(o *DefaultMiddlewareOptions) {
+		o.recoverOptions = append(o.recoverOptions, opts...)
+	}
+}
+
+// DefaultMiddleware returns the recommended middleware chain in the correct order:
+//   - RequestIDMiddleware, so all following middlewares and handlers have the request ID,
+//   - RoutePatternMiddleware, so logs and metrics have the route pattern,
+//   - LoggingMiddleware, which logs the request including the panic recovered by the next middleware,
+//   - RecoverMiddleware, which recovers panics of handlers and writes the error response.
+//
+// Other middlewares (e.g. RealIPMiddleware or TraceContextMiddleware) can be registered before or after the chain.
+// It is intended to be registered with chi.Router.Use, e.g. r.Use(httpx.DefaultMiddleware(logger)...).
+func DefaultMiddleware(l *slog.Logger, opts ...DefaultMiddlewareOption) []func(http.Handler) http.Handler {
+	o := DefaultMiddlewareOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return []func(http.Handler) http.Handler{
+		RequestIDMiddleware(o.requestIDFunc, o.requestIDOptions...),
+		RoutePatternMiddleware,
+		LoggingMiddleware(l, o.loggingOptions...),
+		RecoverMiddleware(l, o.recoverOptions...),
+	}
+}
+
+// NewRouter returns a chi router with DefaultMiddleware, and NotFoundHandler and MethodNotAllowedHandler
+// writing JSON error responses.
+func NewRouter(l *slog.Logger, opts ...DefaultMiddlewareOption) *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(DefaultMiddleware(l, opts...)...)
+	r.NotFound(NotFoundHandler)
+	r.MethodNotAllowed(MethodNotAllowedHandler(r))
+	return r
+}
+
+// RoutePatternMiddleware saves the route pattern matched by chi router into the context
+// (see net.RoutePatternFromCtx). It has to be registered with chi.Router.Use.
+func RoutePatternMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil || net.RoutePatternFromCtx(r.Context()) != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := net.WithRoutePattern(r.Context(), rctx.RoutePattern)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// NotFoundHandler writes http.StatusNotFound error response with ERR_NOT_FOUND error code.
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	_ = WriteRequestErrorResponse(w, r, http.StatusNotFound, WithErrorCode(errCodeNotFound))
+}
+
+// MethodNotAllowedHandler returns a handler writing http.StatusMethodNotAllowed error response
+// with ERR_METHOD_NOT_ALLOWED error code. If routes are not nil, the Allow header lists methods
+// of the route matching the path.
+func MethodNotAllowedHandler(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if routes != nil {
+			if allowed := allowedMethods(routes, r.URL.Path); len(allowed) > 0 {
+				w.Header().Set(Header.Allow, strings.Join(allowed, ", "))
+			}
+		}
+		_ = WriteRequestErrorResponse(w, r, http.StatusMethodNotAllowed, WithErrorCode(errCodeMethodNotAllowed))
+	}
+}
+
+func allowedMethods(routes chi.Routes, path string) []string {
+	var allowed []string
+	for _, method := range []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+	} {
+		if routes.Match(chi.NewRouteContext(), method, path) {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+)
+
+func TestNewRouter(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantAllow  string
+		wantRoute  string
+		wantPanic  bool
+	}{
+		{
+			name:       "success:route",
+			method:     http.MethodGet,
+			path:       "/users/1",
+			wantStatus: http.StatusOK,
+			wantRoute:  "/users/{id}",
+		},
+		{
+			name:       "success:sub-router",
+			method:     http.MethodGet,
+			path:       "/api/orders/1",
+			wantStatus: http.StatusOK,
+			wantRoute:  "/api/orders/{id}",
+		},
+		{
+			name:       "failure:panic",
+			method:     http.MethodGet,
+			path:       "/panic",
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "ERR_INTERNAL",
+			wantRoute:  "/panic",
+			wantPanic:  true,
+		},
+		{
+			name:       "failure:not-found",
+			method:     http.MethodGet,
+			path:       "/missing",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "ERR_NOT_FOUND",
+		},
+		{
+			name:       "failure:method-not-allowed",
+			method:     http.MethodDelete,
+			path:       "/users/1",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantCode:   "ERR_METHOD_NOT_ALLOWED",
+			wantAllow:  "GET, PUT",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &bytes.Buffer{}
+			l := slog.New(slog.NewJSONHandler(logs, nil))
+
+			var handlerRoute string
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				handlerRoute = net.RoutePatternFromCtx(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}
+			r := NewRouter(l, WithRequestIDOptions(WithRequestIDGenerator(func() string { return "request-1" })))
+			r.Get("/users/{id}", handler)
+			r.Put("/users/{id}", handler)
+			r.Get("/panic", func(http.ResponseWriter, *http.Request) {
+				panic("boom")
+			})
+			r.Route("/api", func(r chi.Router) {
+				r.Get("/orders/{id}", handler)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, "request-1", w.Header().Get(Header.XRequestID))
+			assert.Equal(t, tt.wantAllow, w.Header().Get(Header.Allow))
+			if tt.wantCode != "" {
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantCode, body["errorCode"])
+				assert.Equal(t, "request-1", body["requestId"])
+			} else {
+				assert.Equal(t, tt.wantRoute, handlerRoute)
+			}
+
+			// The panic is logged by RecoverMiddleware first, the request by LoggingMiddleware last.
+			lines := bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n"))
+			var entry struct {
+				Panic   any            `json:"panic"`
+				Request map[string]any `json:"request"`
+			}
+			require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+			assert.Equal(t, "request-1", entry.Request["id"])
+			assert.Equal(t, float64(tt.wantStatus), entry.Request["status_code"])
+			assert.Equal(t, tt.wantPanic, entry.Panic != nil)
+			if tt.wantPanic {
+				require.Len(t, lines, 2)
+				assert.Contains(t, string(lines[0]), `"request_id":"request-1"`)
+			}
+		})
+	}
+}
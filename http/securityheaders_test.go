@@ -0,0 +1,121 @@
+package http
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SecurityHeadersConfig
+		testFn func(*testing.T, http.Header, string)
+	}{
+		{
+			name:   "success:default",
+			config: DefaultSecurityHeadersConfig(),
+			testFn: func(t *testing.T, h http.Header, nonce string) {
+				t.Helper()
+				assert.Equal(t, "max-age=63072000; includeSubDomains", h.Get("Strict-Transport-Security"))
+				assert.Equal(t, "nosniff", h.Get("X-Content-Type-Options"))
+				assert.Equal(t, "DENY", h.Get("X-Frame-Options"))
+				assert.Equal(t, "no-referrer", h.Get("Referrer-Policy"))
+				assert.Equal(t, "same-origin", h.Get("Cross-Origin-Opener-Policy"))
+				assert.Equal(t, "require-corp", h.Get("Cross-Origin-Embedder-Policy"))
+				assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", h.Get("Content-Security-Policy"))
+				assert.Empty(t, h.Get("Permissions-Policy"))
+				assert.Empty(t, nonce)
+			},
+		},
+		{
+			name: "success:nonce",
+			config: SecurityHeadersConfig{
+				ContentSecurityPolicy: "script-src 'self' 'nonce-{nonce}'; style-src 'nonce-{nonce}'",
+			},
+			testFn: func(t *testing.T, h http.Header, nonce string) {
+				t.Helper()
+				require.NotEmpty(t, nonce)
+				assert.Equal(
+					t,
+					"script-src 'self' 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+"'",
+					h.Get("Content-Security-Policy"),
+				)
+				assert.Empty(t, h.Get("X-Frame-Options"))
+			},
+		},
+		{
+			name: "success:report-only",
+			config: SecurityHeadersConfig{
+				ContentSecurityPolicy: "default-src 'self';",
+				CSPReportOnly:         true,
+				CSPReportURI:          "/csp-report",
+			},
+			testFn: func(t *testing.T, h http.Header, _ string) {
+				t.Helper()
+				assert.Empty(t, h.Get("Content-Security-Policy"))
+				assert.Equal(t, "default-src 'self'; report-uri /csp-report", h.Get("Content-Security-Policy-Report-Only"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nonce string
+			h := SecurityHeadersMiddleware(tt.config)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				nonce = net.CSPNonceFromCtx(r.Context())
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			tt.testFn(t, rec.Header(), nonce)
+		})
+	}
+}
+
+func TestCSPReportHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantLogs   int
+	}{
+		{
+			name:       "success:report-uri",
+			body:       `{"csp-report":{"document-uri":"https://example.com/","violated-directive":"script-src","blocked-uri":"inline"}}`,
+			wantStatus: http.StatusNoContent,
+			wantLogs:   1,
+		},
+		{
+			name: "success:reporting-api",
+			body: `[
+				{"type":"csp-violation","body":{"documentURL":"https://example.com/","effectiveDirective":"img-src","blockedURL":"https://evil.com/a.png"}},
+				{"type":"deprecation","body":{}}
+			]`,
+			wantStatus: http.StatusNoContent,
+			wantLogs:   1,
+		},
+		{
+			name:       "failure:invalid-body",
+			body:       `{`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			h := CSPReportHandler(slog.New(slog.NewJSONHandler(buf, nil)))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/csp-report", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantLogs, strings.Count(buf.String(), `"msg":"csp violation"`))
+		})
+	}
+}
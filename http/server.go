@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -60,6 +61,14 @@ func NewServer(config *ServerConfig) *Server {
 		}
 	}
 
+	if hooks := config.Hooks.ConnState; len(hooks) > 0 {
+		s.server.ConnState = func(c net.Conn, state http.ConnState) {
+			for _, f := range hooks {
+				f(c, state)
+			}
+		}
+	}
+
 	s.server.RegisterOnShutdown(s.beforeShutdown)
 	return s
 }
@@ -148,6 +157,12 @@ type ServerHooks struct {
 	// Passed context is canceled after ShutdownTimeout passes, but at that point, completion of the hook
 	// is not waited for anymore (as Run returns after such timeout).
 	BeforeShutdown []ServerHookFunc
+
+	// Each ConnStateHookFunc is called when a client connection changes state (see http.Server.ConnState),
+	// e.g. for collecting connection metrics. The hooks are called synchronously, so they should return quickly.
+	ConnState []ConnStateHookFunc
 }
 
 type ServerHookFunc func(context.Context)
+
+type ConnStateHookFunc func(net.Conn, http.ConnState)
@@ -6,9 +6,22 @@ import (
 	"fmt"
 	"net/http"
 
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	neterrors "go.strv.io/net/errors"
 	httpx "go.strv.io/net/http"
 )
 
+const (
+	// SpanNameInputGet is the name of the span of the first step (parsing input), see Wrapper.WithTracer.
+	SpanNameInputGet = "signature.input_get"
+	// SpanNameInnerHandler is the name of the span of the second step (inner handler), see Wrapper.WithTracer.
+	SpanNameInnerHandler = "signature.inner_handler"
+	// SpanNameResponseMarshal is the name of the span of the third step (marshaling response), see Wrapper.WithTracer.
+	SpanNameResponseMarshal = "signature.response_marshal"
+)
+
 var (
 	// ErrInputGet is passed to ErrorHandlerFunc when WrapHandler (or derived) fails in the first step (parsing input)
 	ErrInputGet = errors.New("parsing input")
@@ -46,6 +59,7 @@ type Wrapper struct {
 	inputGetter       InputGetterFunc
 	responseMarshaler ResponseMarshalerFunc
 	errorHandler      ErrorHandlerFunc
+	tracer            trace.Tracer
 }
 
 // DefaultWrapper Creates a Wrapper with default functions for each needed step.
@@ -84,6 +98,31 @@ func (w Wrapper) WithErrorHandler(f ErrorHandlerFunc) Wrapper {
 	return w
 }
 
+// WithTracer returns a copy of Wrapper that starts a child span (see SpanNameInputGet, SpanNameInnerHandler
+// and SpanNameResponseMarshal) for each step. The span of a step is available in the context of the request
+// passed to the step, and it is marked as failed if the step returns an error.
+func (w Wrapper) WithTracer(t trace.Tracer) Wrapper {
+	w.tracer = t
+	return w
+}
+
+// step runs a step in its own child span if the tracer is set. The span is ended when the step returns or panics,
+// and it is marked as failed if the step returns an error.
+func (w Wrapper) step(r *http.Request, name string, f func(r *http.Request) error) (err error) {
+	if w.tracer == nil {
+		return f(r)
+	}
+	ctx, span := w.tracer.Start(r.Context(), name)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	return f(r.WithContext(ctx))
+}
+
 func inputErrorWithType(target any, innerError error) error {
 	return fmt.Errorf("%w into type %T: %w", ErrInputGet, target, innerError)
 }
@@ -109,17 +148,25 @@ func wrapInnerHandlerError(innerError error) error {
 func WrapHandler[TInput any, TResponse any](wrapper Wrapper, handler func(http.ResponseWriter, *http.Request, TInput) (TResponse, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var input TInput
-		err := wrapper.inputGetter(r, &input)
+		err := wrapper.step(r, SpanNameInputGet, func(sr *http.Request) error {
+			return wrapper.inputGetter(sr, &input)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, inputErrorWithType(input, err))
 			return
 		}
-		response, err := handler(w, r, input)
+		var response TResponse
+		err = wrapper.step(r, SpanNameInnerHandler, func(sr *http.Request) (err error) {
+			response, err = handler(w, sr, input)
+			return err
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, wrapInnerHandlerError(err))
 			return
 		}
-		err = wrapper.responseMarshaler(w, r, response)
+		err = wrapper.step(r, SpanNameResponseMarshal, func(sr *http.Request) error {
+			return wrapper.responseMarshaler(w, sr, response)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, responseErrorWithType(response, err))
 			return
@@ -132,12 +179,18 @@ func WrapHandler[TInput any, TResponse any](wrapper Wrapper, handler func(http.R
 // Compared to WrapHandler, the first step is skipped (no parsed input for inner handler is provided)
 func WrapHandlerResponse[TResponse any](wrapper Wrapper, handler func(http.ResponseWriter, *http.Request) (TResponse, error)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		response, err := handler(w, r)
+		var response TResponse
+		err := wrapper.step(r, SpanNameInnerHandler, func(sr *http.Request) (err error) {
+			response, err = handler(w, sr)
+			return err
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, wrapInnerHandlerError(err))
 			return
 		}
-		err = wrapper.responseMarshaler(w, r, response)
+		err = wrapper.step(r, SpanNameResponseMarshal, func(sr *http.Request) error {
+			return wrapper.responseMarshaler(w, sr, response)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, responseErrorWithType(response, err))
 			return
@@ -152,17 +205,23 @@ func WrapHandlerResponse[TResponse any](wrapper Wrapper, handler func(http.Respo
 func WrapHandlerInput[TInput any](wrapper Wrapper, handler func(http.ResponseWriter, *http.Request, TInput) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var input TInput
-		err := wrapper.inputGetter(r, &input)
+		err := wrapper.step(r, SpanNameInputGet, func(sr *http.Request) error {
+			return wrapper.inputGetter(sr, &input)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, inputErrorWithType(input, err))
 			return
 		}
-		err = handler(w, r, input)
+		err = wrapper.step(r, SpanNameInnerHandler, func(sr *http.Request) error {
+			return handler(w, sr, input)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, wrapInnerHandlerError(err))
 			return
 		}
-		err = wrapper.responseMarshaler(w, r, http.NoBody)
+		err = wrapper.step(r, SpanNameResponseMarshal, func(sr *http.Request) error {
+			return wrapper.responseMarshaler(w, sr, http.NoBody)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, responseErrorWithType(nil, err))
 			return
@@ -177,12 +236,16 @@ func WrapHandlerInput[TInput any](wrapper Wrapper, handler func(http.ResponseWri
 // (and as such, the ResponseMarshalerFunc should handle the http.NoBody value gracefully)
 func WrapHandlerError(wrapper Wrapper, handler func(http.ResponseWriter, *http.Request) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := handler(w, r)
+		err := wrapper.step(r, SpanNameInnerHandler, func(sr *http.Request) error {
+			return handler(w, sr)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, wrapInnerHandlerError(err))
 			return
 		}
-		err = wrapper.responseMarshaler(w, r, http.NoBody)
+		err = wrapper.step(r, SpanNameResponseMarshal, func(sr *http.Request) error {
+			return wrapper.responseMarshaler(w, sr, http.NoBody)
+		})
 		if err != nil {
 			wrapper.errorHandler(w, r, responseErrorWithType(nil, err))
 			return
@@ -200,20 +263,62 @@ func UnmarshalRequestBody(r *http.Request, target any) error {
 	return nil
 }
 
+// ContentTypeInputGetter returns an InputGetterFunc that decodes the request body by the decoder of Content-Type
+// of the request, httpx.DefaultDecoders are used if none is given. If the request has no Content-Type,
+// the first decoder is used. If none of the decoders accepts the content type, the returned error
+// wraps httpx.ErrUnsupportedMediaType, which is written as 415 Unsupported Media Type by InputGetErrorHandle.
+func ContentTypeInputGetter(decoders ...httpx.Decoder) InputGetterFunc {
+	return func(r *http.Request, dest any) error {
+		return httpx.DecodeRequestBody(r, dest, decoders...)
+	}
+}
+
 // FixedResponseCodeMarshal returns a ResponseMarshalerFunc that always writes provided http status code on success.
+// If the response object implements httpx.ETagger or httpx.LastModifier, ETag and Last-Modified headers are set.
 func FixedResponseCodeMarshal(statusCode int) ResponseMarshalerFunc {
 	return func(w http.ResponseWriter, _ *http.Request, obj any) error {
+		etag, lastModified := httpx.Validators(obj)
+		httpx.SetValidators(w.Header(), etag, lastModified)
 		return httpx.WriteResponse(w, obj, statusCode)
 	}
 }
 
 // DefaultResponseMarshal is a ResponseMarshalerFunc that writes 200 OK http status code with JSON marshaled object.
 // 204 No Content http status code is returned if no response object is provided (i.e. when using WrapHandlerInput or WrapHandlerError)
-func DefaultResponseMarshal(w http.ResponseWriter, _ *http.Request, src any) error {
+//
+// If the response object implements httpx.ETagger or httpx.LastModifier, ETag and Last-Modified headers are set,
+// and for GET and HEAD requests, 304 Not Modified is written if the client already has the current representation.
+// If a precondition (e.g. If-Match) fails, the returned error wraps httpx.ErrPreconditionFailed.
+func DefaultResponseMarshal(w http.ResponseWriter, r *http.Request, src any) error {
+	return marshalResponse(w, r, src)
+}
+
+// NegotiatedResponseMarshal returns a ResponseMarshalerFunc that behaves like DefaultResponseMarshal,
+// but encodes the object by one of the encoders (httpx.DefaultEncoders if none is given) according to Accept header
+// of the request. If none of the encoders is acceptable, 406 Not Acceptable error response is written.
+func NegotiatedResponseMarshal(encoders ...httpx.Encoder) ResponseMarshalerFunc {
+	return func(w http.ResponseWriter, r *http.Request, src any) error {
+		return marshalResponse(w, r, src, httpx.WithNegotiation(r, encoders...))
+	}
+}
+
+func marshalResponse(w http.ResponseWriter, r *http.Request, src any, opts ...httpx.ResponseOption) error {
 	if src == http.NoBody {
 		return httpx.WriteResponse(w, src, http.StatusNoContent)
 	}
-	return httpx.WriteResponse(w, src, http.StatusOK)
+	etag, lastModified := httpx.Validators(src)
+	if etag != "" || !lastModified.IsZero() {
+		httpx.SetValidators(w.Header(), etag, lastModified)
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			if err := httpx.CheckPreconditions(r, etag, lastModified); err != nil {
+				if errors.Is(err, httpx.ErrNotModified) {
+					return httpx.WritePreconditionResponse(w, err)
+				}
+				return err
+			}
+		}
+	}
+	return httpx.WriteResponse(w, src, http.StatusOK, opts...)
 }
 
 // AlwaysInternalErrorHandle is a function usable as ErrorHandlerFunc.
@@ -224,13 +329,41 @@ func AlwaysInternalErrorHandle(w http.ResponseWriter, _ *http.Request, _ error)
 }
 
 // InputGetErrorHandle is a function usable as ErrorHandlerFunc.
-// It writes a 400 Bad Request http status code to http.ResponseWriter if the error is from parsing input.
+// It writes a 400 Bad Request http status code to http.ResponseWriter if the error is from parsing input,
+// or 415 Unsupported Media Type if the input error wraps httpx.ErrUnsupportedMediaType.
+// If the input error describes invalid input (see httpx.ViolationsFromError), or the inner handler returned
+// httpx.ValidationError, the violations are written as errorData with ERR_VALIDATION error code.
+// If the error wraps httpx.ErrNotModified or httpx.ErrPreconditionFailed (e.g. inner handler returned
+// the result of httpx.CheckPreconditions), 304 Not Modified or 412 Precondition Failed is written.
+// If the error wraps an application error (see neterrors.Error), the status code, code and public message
+// follow its kind, see httpx.WriteAppErrorResponse.
 // Otherwise, writes 500 Internal Server Error http status code on error.
-// In either case, error message is not returned in response and is lost
+// Apart from the public message of application errors, error message is not returned in response and is lost
 func InputGetErrorHandle(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrInputGet) && errors.Is(err, httpx.ErrUnsupportedMediaType) {
+		_ = httpx.WriteErrorResponse(w, http.StatusUnsupportedMediaType)
+		return
+	}
 	if errors.Is(err, ErrInputGet) {
+		if violations, ok := httpx.ViolationsFromError(err); ok {
+			_ = httpx.WriteErrorResponse(w, http.StatusBadRequest, httpx.WithError(err), httpx.WithViolations(violations...))
+			return
+		}
 		_ = httpx.WriteErrorResponse(w, http.StatusBadRequest)
 		return
 	}
+	var validationErr *httpx.ValidationError
+	if errors.As(err, &validationErr) {
+		_ = httpx.WriteErrorResponse(w, http.StatusBadRequest, httpx.WithError(err), httpx.WithViolations(validationErr.Violations...))
+		return
+	}
+	if errors.Is(err, httpx.ErrNotModified) || errors.Is(err, httpx.ErrPreconditionFailed) {
+		_ = httpx.WritePreconditionResponse(w, err)
+		return
+	}
+	if _, ok := neterrors.AsError(err); ok {
+		_ = httpx.WriteAppErrorResponse(w, err)
+		return
+	}
 	AlwaysInternalErrorHandle(w, r, err)
 }
@@ -1,6 +1,7 @@
 package signature_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,7 +14,12 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
+	neterrors "go.strv.io/net/errors"
+	httpx "go.strv.io/net/http"
 	httpparam "go.strv.io/net/http/param"
 	"go.strv.io/net/http/signature"
 )
@@ -211,6 +217,7 @@ func TestWrapper_Error(t *testing.T) {
 		{
 			name:           "parsing body returns 400",
 			inputBody:      `{"incomplete_json":`,
+			expectedBody:   `{"errorCode":"ERR_VALIDATION","errorData":{"violations":[{"in":"body","code":"invalid_syntax","message":"unexpected end of body"}]}}`,
 			expectedStatus: http.StatusBadRequest,
 			handler:        signature.WrapHandler(w, buggyHandler),
 			targetErr:      signature.ErrInputGet,
@@ -240,6 +247,7 @@ func TestWrapper_Error(t *testing.T) {
 		{
 			name:           "parsing body returns 400 (only input)",
 			inputBody:      `{"incomplete_json":`,
+			expectedBody:   `{"errorCode":"ERR_VALIDATION","errorData":{"violations":[{"in":"body","code":"invalid_syntax","message":"unexpected end of body"}]}}`,
 			expectedStatus: http.StatusBadRequest,
 			handler:        signature.WrapHandlerInput(w, buggyHandlerInput),
 			targetErr:      signature.ErrInputGet,
@@ -294,7 +302,10 @@ func TestWrapper_Error(t *testing.T) {
 			if tc.isABug {
 				require.ErrorIs(t, interceptedError, errBug)
 			}
-			assert.JSONEq(t, `{"errorCode":"ERR_UNKNOWN"}`, rec.Body.String())
+			if tc.expectedBody == "" {
+				tc.expectedBody = `{"errorCode":"ERR_UNKNOWN"}`
+			}
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
 		})
 	}
 }
@@ -338,3 +349,294 @@ func buggyHandlerInput(_ http.ResponseWriter, _ *http.Request, input buggyInputO
 func buggyHandlerError(_ http.ResponseWriter, _ *http.Request) error {
 	return errBug
 }
+
+type versionedUser struct {
+	User
+	Version int `json:"-"`
+}
+
+func (u versionedUser) ETag() string {
+	return fmt.Sprintf("user-%d", u.Version)
+}
+
+func TestDefaultResponseMarshal_Conditional(t *testing.T) {
+	handler := signature.WrapHandlerResponse(signature.DefaultWrapper(), func(_ http.ResponseWriter, _ *http.Request) (versionedUser, error) {
+		return versionedUser{User: User{UserName: "Testowic"}, Version: 2}, nil
+	})
+
+	testCases := []struct {
+		name           string
+		ifNoneMatch    string
+		ifMatch        string
+		expectedStatus int
+	}{
+		{
+			name:           "without conditions returns 200",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "matching If-None-Match returns 304",
+			ifNoneMatch:    `"user-2"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "outdated If-None-Match returns 200",
+			ifNoneMatch:    `"user-1"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "outdated If-Match returns 412",
+			ifMatch:        `"user-1"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "https://test.com/users/1", nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, `"user-2"`, rec.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestNegotiatedResponseMarshal(t *testing.T) {
+	wrapper := signature.DefaultWrapper().WithResponseMarshaler(signature.NegotiatedResponseMarshal())
+	handler := signature.WrapHandlerResponse(wrapper, func(_ http.ResponseWriter, _ *http.Request) (User, error) {
+		return User{UserName: "Testowic", Group: 1}, nil
+	})
+
+	testCases := []struct {
+		name                string
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "JSON by default",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        `{"user_name":"Testowic","group":1}` + "\n",
+		},
+		{
+			name:                "YAML if preferred",
+			accept:              "application/json;q=0.5, application/yaml",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/yaml; charset=utf-8",
+			expectedBody:        "user_name: Testowic\ngroup: 1\n",
+		},
+		{
+			name:                "406 if not acceptable",
+			accept:              "image/png",
+			expectedStatus:      http.StatusNotAcceptable,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        `{"errorCode":"ERR_NOT_ACCEPTABLE"}` + "\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "https://test.com/users/1", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, tc.expectedContentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestWrapper_WithTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test")
+	w := signature.DefaultWrapper().
+		WithInputGetter(parseInputFunc).
+		WithTracer(tracer)
+
+	testCases := []struct {
+		name          string
+		handler       http.HandlerFunc
+		inputBody     string
+		expectedSpans []string
+		failedSpan    string
+	}{
+		{
+			name:          "all steps",
+			handler:       signature.WrapHandler(w, listUsersHandler),
+			expectedSpans: []string{signature.SpanNameInputGet, signature.SpanNameInnerHandler, signature.SpanNameResponseMarshal},
+		},
+		{
+			name:          "without input",
+			handler:       signature.WrapHandlerError(w, healthcheckHandler),
+			expectedSpans: []string{signature.SpanNameInnerHandler, signature.SpanNameResponseMarshal},
+		},
+		{
+			name:          "failed input",
+			handler:       signature.WrapHandlerInput(w, createUserHandler),
+			inputBody:     `{"user_name":`,
+			expectedSpans: []string{signature.SpanNameInputGet},
+			failedSpan:    signature.SpanNameInputGet,
+		},
+		{
+			name:          "failed inner handler",
+			handler:       signature.WrapHandlerError(w, buggyHandlerError),
+			expectedSpans: []string{signature.SpanNameInnerHandler},
+			failedSpan:    signature.SpanNameInnerHandler,
+		},
+		{
+			name: "panicking inner handler",
+			handler: signature.WrapHandlerError(w, func(http.ResponseWriter, *http.Request) error {
+				panic("inner handler panic")
+			}),
+			expectedSpans: []string{signature.SpanNameInnerHandler},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter.Reset()
+			ctx, parent := tracer.Start(context.Background(), "parent")
+			req := httptest.NewRequestWithContext(ctx, http.MethodPost, "https://test.com/users", strings.NewReader(tc.inputBody))
+
+			func() {
+				defer func() { _ = recover() }()
+				tc.handler.ServeHTTP(httptest.NewRecorder(), req)
+			}()
+			parent.End()
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, len(tc.expectedSpans)+1)
+			for i, name := range tc.expectedSpans {
+				assert.Equal(t, name, spans[i].Name)
+				assert.Equal(t, parent.SpanContext().SpanID(), spans[i].Parent.SpanID())
+				if name == tc.failedSpan {
+					assert.Equal(t, codes.Error, spans[i].Status.Code)
+				} else {
+					assert.Equal(t, codes.Unset, spans[i].Status.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestContentTypeInputGetter(t *testing.T) {
+	wrapper := signature.DefaultWrapper().WithInputGetter(signature.ContentTypeInputGetter())
+	handler := signature.WrapHandlerInput(wrapper, func(_ http.ResponseWriter, _ *http.Request, input CreateUserInput) error {
+		if input.UserName != "Testowic" {
+			return fmt.Errorf("unexpected input %+v", input)
+		}
+		return nil
+	})
+
+	testCases := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "JSON",
+			contentType:    "application/json",
+			body:           `{"user_name":"Testowic","group":1}`,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "YAML",
+			contentType:    "application/yaml",
+			body:           "user_name: Testowic\ngroup: 1\n",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "415 if unsupported",
+			contentType:    "image/png",
+			body:           "png",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "400 if invalid",
+			contentType:    "application/json",
+			body:           "{",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "https://test.com/users", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestInputGetErrorHandle_AppError(t *testing.T) {
+	handler := signature.WrapHandlerResponse(signature.DefaultWrapper(), func(_ http.ResponseWriter, _ *http.Request) (User, error) {
+		return User{}, neterrors.New(neterrors.KindNotFound, "ERR_USER_NOT_FOUND", "user not found", neterrors.WithCause(errBug))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "https://test.com/users/1", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"errorCode":"ERR_USER_NOT_FOUND","errorMessage":"user not found"}`, rec.Body.String())
+}
+
+func TestInputGetErrorHandle_Violations(t *testing.T) {
+	wrapper := signature.DefaultWrapper().WithInputGetter(parseInputFunc)
+	r := chi.NewRouter()
+	r.Post("/groups/{group}/users", signature.WrapHandlerInput(wrapper, func(_ http.ResponseWriter, _ *http.Request, input CreateUserInput) error {
+		var v httpx.ValidationError
+		if input.UserName == "" {
+			v.Add(httpx.Violation{In: httpx.ViolationInBody, Pointer: "/user_name", Code: "required"})
+		}
+		return v.Err()
+	}))
+
+	testCases := []struct {
+		name         string
+		path         string
+		body         string
+		expectedBody string
+	}{
+		{
+			name:         "invalid type",
+			path:         "/groups/1/users",
+			body:         `{"user_name":"Testowic","group":"first"}`,
+			expectedBody: `{"errorCode":"ERR_VALIDATION","errorData":{"violations":[{"in":"body","pointer":"/group","code":"invalid_type","message":"expected number","params":{"expected":"number","actual":"string"}}]}}`,
+		},
+		{
+			name:         "custom validation",
+			path:         "/groups/1/users",
+			body:         `{"group":1}`,
+			expectedBody: `{"errorCode":"ERR_VALIDATION","errorData":{"violations":[{"in":"body","pointer":"/user_name","code":"required"}]}}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "https://test.com"+tc.path, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+		})
+	}
+}
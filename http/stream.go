@@ -0,0 +1,247 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/http"
+	"time"
+
+	"go.strv.io/net"
+)
+
+const (
+	defaultStreamFlushEvery    = 100
+	defaultStreamFlushInterval = time.Second
+)
+
+// StreamFormat is the format of streamed responses.
+type StreamFormat int
+
+const (
+	// StreamNDJSON writes each item as a JSON value on a separate line (NDJSON, JSON Lines).
+	StreamNDJSON StreamFormat = iota
+	// StreamJSONArray writes items as elements of a JSON array.
+	StreamJSONArray
+)
+
+type StreamOptions struct {
+	format        StreamFormat
+	flushEvery    int
+	flushInterval time.Duration
+}
+
+type StreamOption func(*StreamOptions)
+
+// WithStreamFormat sets the format of the stream. Default is StreamNDJSON.
+func WithStreamFormat(f StreamFormat) StreamOption {
+	return func(o *StreamOptions) {
+		o.format = f
+	}
+}
+
+// WithFlushEvery flushes the response after every n items, 0 disables it. Default is 100.
+func WithFlushEvery(n int) StreamOption {
+	return func(o *StreamOptions) {
+		o.flushEvery = n
+	}
+}
+
+// WithFlushInterval flushes the response if the interval elapsed since the last flush, 0 disables it. Default is 1s.
+func WithFlushInterval(d time.Duration) StreamOption {
+	return func(o *StreamOptions) {
+		o.flushInterval = d
+	}
+}
+
+// streamTrailer is written as the last item if the stream fails.
+type streamTrailer struct {
+	Error ErrorResponseOptions `json:"error"`
+}
+
+// StreamSeq2 writes items of the sequence as a 200 OK streamed response (see StreamFormat), without building
+// the whole response in memory. The response is flushed according to WithFlushEvery and WithFlushInterval,
+// and at the end of the stream.
+//
+// If the sequence yields an error, or an item cannot be encoded, the stream is ended by a trailing error object
+// {"error": {...}} with the request ID, the trace ID (see TraceContextMiddleware), and the code and public message
+// of the application error (see WithAppError).
+// The error is set as the error object of ResponseWriter and returned.
+//
+// If the context of the request is cancelled, the stream is stopped without writing anything else,
+// and the error of the context is returned.
+func StreamSeq2[T any](w http.ResponseWriter, r *http.Request, seq iter.Seq2[T, error], opts ...StreamOption) error {
+	s := newStreamWriter(w, opts)
+	ctx := r.Context()
+	if err := s.begin(); err != nil {
+		return err
+	}
+	var err error
+	for item, itemErr := range seq {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		if itemErr != nil {
+			err = itemErr
+			break
+		}
+		if err = s.writeItem(item); err != nil {
+			break
+		}
+	}
+	return s.end(r, err)
+}
+
+// StreamSeq writes items of the sequence as a streamed response, see StreamSeq2.
+func StreamSeq[T any](w http.ResponseWriter, r *http.Request, seq iter.Seq[T], opts ...StreamOption) error {
+	return StreamSeq2(w, r, func(yield func(T, error) bool) {
+		for item := range seq {
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}, opts...)
+}
+
+// StreamChan writes items received from the channel as a streamed response until the channel is closed,
+// see StreamSeq2. While waiting for items, the response is flushed after the flush interval.
+func StreamChan[T any](w http.ResponseWriter, r *http.Request, ch <-chan T, opts ...StreamOption) error {
+	s := newStreamWriter(w, opts)
+	ctx := r.Context()
+	if err := s.begin(); err != nil {
+		return err
+	}
+	var tick <-chan time.Time
+	if s.o.flushInterval > 0 {
+		ticker := time.NewTicker(s.o.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return s.end(r, ctx.Err())
+		case <-tick:
+			if s.unflushed > 0 {
+				s.flush()
+			}
+		case item, ok := <-ch:
+			if !ok {
+				return s.end(r, nil)
+			}
+			if err := s.writeItem(item); err != nil {
+				return s.end(r, err)
+			}
+		}
+	}
+}
+
+type streamWriter struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	o         StreamOptions
+	count     int
+	unflushed int
+	lastFlush time.Time
+	// writeErr is the error of writing into the response, nothing else can be written after it.
+	writeErr error
+}
+
+func newStreamWriter(w http.ResponseWriter, opts []StreamOption) *streamWriter {
+	o := StreamOptions{
+		format:        StreamNDJSON,
+		flushEvery:    defaultStreamFlushEvery,
+		flushInterval: defaultStreamFlushInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &streamWriter{
+		w:         w,
+		rc:        http.NewResponseController(w),
+		o:         o,
+		lastFlush: time.Now(),
+	}
+}
+
+func (s *streamWriter) begin() error {
+	contentType := ApplicationNDJSON
+	if s.o.format == StreamJSONArray {
+		contentType = ApplicationJSON
+	}
+	s.w.Header().Set(Header.ContentType, contentType.WithCharset(UTF8).String())
+	s.w.WriteHeader(http.StatusOK)
+	if s.o.format == StreamJSONArray {
+		return s.write([]byte("["))
+	}
+	return nil
+}
+
+func (s *streamWriter) writeItem(item any) error {
+	b, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if s.o.format == StreamJSONArray && s.count > 0 {
+		if err := s.write([]byte(",")); err != nil {
+			return err
+		}
+	}
+	if s.o.format == StreamNDJSON {
+		b = append(b, '\n')
+	}
+	if err := s.write(b); err != nil {
+		return err
+	}
+	s.count++
+	s.unflushed++
+	if (s.o.flushEvery > 0 && s.unflushed >= s.o.flushEvery) ||
+		(s.o.flushInterval > 0 && time.Since(s.lastFlush) >= s.o.flushInterval) {
+		s.flush()
+	}
+	return nil
+}
+
+// end writes the trailing error object if the stream failed, and closes the stream.
+func (s *streamWriter) end(r *http.Request, err error) error {
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+	if r.Context().Err() != nil {
+		return r.Context().Err()
+	}
+	if err != nil {
+		if rw, ok := UnwrapResponseWriter(s.w); ok {
+			rw.SetErrorObject(err)
+		}
+		o := defaultErrorOptions()
+		WithRequestID(net.RequestIDFromCtx(r.Context()))(&o)
+		WithTraceID(traceIDFromHeader(s.w.Header()))(&o)
+		WithAppError(err)(&o)
+		if writeErr := s.writeItem(streamTrailer{Error: o}); writeErr != nil {
+			return errors.Join(err, writeErr)
+		}
+	}
+	if s.o.format == StreamJSONArray {
+		if writeErr := s.write([]byte("]")); writeErr != nil {
+			return errors.Join(err, writeErr)
+		}
+	}
+	s.flush()
+	return err
+}
+
+func (s *streamWriter) write(b []byte) error {
+	if _, err := s.w.Write(b); err != nil {
+		s.writeErr = err
+		return err
+	}
+	return nil
+}
+
+func (s *streamWriter) flush() {
+	// Writers not supporting flushing are written at once at the end of the handler.
+	_ = s.rc.Flush()
+	s.unflushed = 0
+	s.lastFlush = time.Now()
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+	neterrors "go.strv.io/net/errors"
+	"go.strv.io/net/internal"
+)
+
+type streamItem struct {
+	ID int `json:"id"`
+}
+
+// flushRecorder counts flushes of the response.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushRecorder) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+func items(n int) iter.Seq2[streamItem, error] {
+	return func(yield func(streamItem, error) bool) {
+		for i := range n {
+			if !yield(streamItem{ID: i + 1}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func failingItems(n int, err error) iter.Seq2[streamItem, error] {
+	return func(yield func(streamItem, error) bool) {
+		for item := range items(n) {
+			if !yield(item, nil) {
+				return
+			}
+		}
+		yield(streamItem{}, err)
+	}
+}
+
+func TestStreamSeq2(t *testing.T) {
+	errDB := errors.New("connection reset")
+	tests := []struct {
+		name            string
+		seq             iter.Seq2[streamItem, error]
+		opts            []StreamOption
+		wantContentType string
+		wantBody        string
+		wantErr         error
+	}{
+		{
+			name:            "success:ndjson",
+			seq:             items(3),
+			wantContentType: "application/x-ndjson; charset=utf-8",
+			wantBody:        "{\"id\":1}\n{\"id\":2}\n{\"id\":3}\n",
+		},
+		{
+			name:            "success:json-array",
+			seq:             items(3),
+			opts:            []StreamOption{WithStreamFormat(StreamJSONArray)},
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `[{"id":1},{"id":2},{"id":3}]`,
+		},
+		{
+			name:            "success:empty-json-array",
+			seq:             items(0),
+			opts:            []StreamOption{WithStreamFormat(StreamJSONArray)},
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `[]`,
+		},
+		{
+			name:            "failure:ndjson-trailer",
+			seq:             failingItems(2, errDB),
+			wantContentType: "application/x-ndjson; charset=utf-8",
+			wantBody:        "{\"id\":1}\n{\"id\":2}\n{\"error\":{\"requestId\":\"request-1\",\"errorCode\":\"ERR_UNKNOWN\"}}\n",
+			wantErr:         errDB,
+		},
+		{
+			name:            "failure:json-array-trailer",
+			seq:             failingItems(1, neterrors.New(neterrors.KindUnavailable, "", "export interrupted", neterrors.WithCause(errDB))),
+			opts:            []StreamOption{WithStreamFormat(StreamJSONArray)},
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `[{"id":1},{"error":{"requestId":"request-1","errorCode":"ERR_UNAVAILABLE","errorMessage":"export interrupted"}}]`,
+			wantErr:         errDB,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/export", nil)
+			r = r.WithContext(net.WithRequestID(r.Context(), "request-1"))
+			rec := httptest.NewRecorder()
+			rw := NewResponseWriter(rec, internal.NewNopLogger())
+
+			err := StreamSeq2(rw, r, tt.seq, tt.opts...)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantContentType, rec.Header().Get(Header.ContentType))
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, rw.ErrorObject(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStreamSeq2_TraceID(t *testing.T) {
+	errDB := errors.New("connection reset")
+	h := TraceContextMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = StreamSeq2(w, r, failingItems(0, errDB))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/export", nil)
+	r.Header.Set(Header.Traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	assert.Equal(t, `{"error":{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","errorCode":"ERR_UNKNOWN"}}`+"\n", rec.Body.String())
+}
+
+func TestStreamSeq_EncodingError(t *testing.T) {
+	w := httptest.NewRecorder()
+	err := StreamSeq(w, httptest.NewRequest(http.MethodGet, "/export", nil), slices.Values([]any{1, func() {}, 3}))
+
+	require.Error(t, err)
+	assert.Equal(t, "1\n{\"error\":{\"errorCode\":\"ERR_UNKNOWN\"}}\n", w.Body.String())
+}
+
+func TestStreamSeq_Flush(t *testing.T) {
+	w := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	seq := func(yield func(streamItem) bool) {
+		for item := range items(5) {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+
+	require.NoError(t, StreamSeq(w, httptest.NewRequest(http.MethodGet, "/export", nil), seq, WithFlushEvery(2), WithFlushInterval(0)))
+
+	// After the 2nd and the 4th item, and at the end.
+	assert.Equal(t, 3, w.flushes)
+}
+
+func TestStreamSeq2_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seq := func(yield func(streamItem, error) bool) {
+		for item := range items(5) {
+			if item.ID == 3 {
+				cancel()
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+	}
+	w := httptest.NewRecorder()
+
+	err := StreamSeq2(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/export", nil), seq)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", w.Body.String())
+}
+
+func TestStreamChan(t *testing.T) {
+	ch := make(chan streamItem)
+	go func() {
+		defer close(ch)
+		for item := range items(3) {
+			ch <- item
+		}
+	}()
+	w := httptest.NewRecorder()
+
+	require.NoError(t, StreamChan(w, httptest.NewRequest(http.MethodGet, "/export", nil), ch, WithStreamFormat(StreamJSONArray)))
+
+	assert.Equal(t, `[{"id":1},{"id":2},{"id":3}]`, w.Body.String())
+}
+
+func TestStreamChan_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan streamItem)
+	go func() {
+		ch <- streamItem{ID: 1}
+		cancel()
+	}()
+	w := httptest.NewRecorder()
+
+	err := StreamChan(w, httptest.NewRequestWithContext(ctx, http.MethodGet, "/export", nil), ch)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "{\"id\":1}\n", w.Body.String())
+}
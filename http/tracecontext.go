@@ -0,0 +1,176 @@
+package http
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"go.strv.io/net"
+)
+
+const (
+	traceparentVersion = "00"
+	traceparentParts   = 4
+	traceIDLength      = 32
+	spanIDLength       = 16
+	traceFlagsLength   = 2
+	amazonRootParts    = 3
+	amazonEpochLength  = 8
+)
+
+type TraceContextMiddlewareOptions struct {
+	amazonTraceID bool
+}
+
+type TraceContextMiddlewareOption func(*TraceContextMiddlewareOptions)
+
+// WithAmazonTraceID makes TraceContextMiddleware read X-Amzn-Trace-Id header (set e.g. by AWS load balancers)
+// if the request does not contain a valid traceparent header, and echo it in the response.
+func WithAmazonTraceID() TraceContextMiddlewareOption {
+	return func(o *TraceContextMiddlewareOptions) {
+		o.amazonTraceID = true
+	}
+}
+
+// TraceContextMiddleware propagates W3C Trace Context (traceparent and tracestate headers).
+//
+// If the request contains a valid traceparent, its trace ID is kept and the caller's span becomes the parent,
+// otherwise a new trace is started. A new span ID is generated for each request. The trace is saved into
+// the context (see net.TraceFromCtx) and echoed in the response headers.
+//
+// LoggingMiddleware, RecoverMiddleware and WriteErrorResponse include the trace ID automatically.
+func TraceContextMiddleware(opts ...TraceContextMiddlewareOption) func(http.Handler) http.Handler {
+	options := TraceContextMiddlewareOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace, ok := parseTraceparent(r.Header.Get(Header.Traceparent))
+			if ok {
+				trace.State = r.Header.Get(Header.Tracestate)
+			} else if options.amazonTraceID {
+				trace, ok = parseAmazonTraceID(r.Header.Get(Header.AmazonTraceID))
+			}
+			if !ok {
+				trace = net.Trace{TraceID: net.NewTraceID(), Sampled: true}
+			}
+			trace.SpanID = net.NewSpanID()
+
+			w.Header().Set(Header.Traceparent, FormatTraceparent(trace))
+			if trace.State != "" {
+				w.Header().Set(Header.Tracestate, trace.State)
+			}
+			if options.amazonTraceID {
+				w.Header().Set(Header.AmazonTraceID, formatAmazonTraceID(trace))
+			}
+
+			ctx := net.WithTrace(r.Context(), trace)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// FormatTraceparent returns traceparent header value for the trace, e.g. for propagating it to outgoing requests.
+func FormatTraceparent(trace net.Trace) string {
+	flags := "00"
+	if trace.Sampled {
+		flags = "01"
+	}
+	return traceparentVersion + "-" + trace.TraceID + "-" + trace.SpanID + "-" + flags
+}
+
+// parseTraceparent parses traceparent header value, e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+// Versions higher than 00 are parsed as 00, as required by the specification.
+func parseTraceparent(value string) (net.Trace, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < traceparentParts {
+		return net.Trace{}, false
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isLowerHex(version, traceFlagsLength) || version == "ff" ||
+		(version == traceparentVersion && len(parts) != traceparentParts) {
+		return net.Trace{}, false
+	}
+	if !isLowerHex(traceID, traceIDLength) || isZeroHex(traceID) ||
+		!isLowerHex(parentID, spanIDLength) || isZeroHex(parentID) ||
+		!isLowerHex(flags, traceFlagsLength) {
+		return net.Trace{}, false
+	}
+	flagBits, _ := hex.DecodeString(flags)
+	return net.Trace{
+		TraceID:      traceID,
+		ParentSpanID: parentID,
+		Sampled:      flagBits[0]&1 == 1,
+	}, true
+}
+
+// parseAmazonTraceID parses X-Amzn-Trace-Id header value,
+// e.g. "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1".
+// The X-Ray trace ID consists of the epoch and the unique part, which together form a valid W3C trace ID.
+func parseAmazonTraceID(value string) (net.Trace, bool) {
+	trace := net.Trace{}
+	for _, field := range strings.Split(value, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "Root":
+			parts := strings.Split(v, "-")
+			if len(parts) != amazonRootParts || parts[0] != "1" {
+				return net.Trace{}, false
+			}
+			trace.TraceID = strings.ToLower(parts[1] + parts[2])
+		case "Parent":
+			trace.ParentSpanID = strings.ToLower(v)
+		case "Sampled":
+			trace.Sampled = v == "1"
+		}
+	}
+	if !isLowerHex(trace.TraceID, traceIDLength) || isZeroHex(trace.TraceID) {
+		return net.Trace{}, false
+	}
+	if !isLowerHex(trace.ParentSpanID, spanIDLength) {
+		trace.ParentSpanID = ""
+	}
+	return trace, true
+}
+
+func formatAmazonTraceID(trace net.Trace) string {
+	sampled := "0"
+	if trace.Sampled {
+		sampled = "1"
+	}
+	return "Root=" + amazonTraceID(trace.TraceID) + ";Parent=" + trace.SpanID + ";Sampled=" + sampled
+}
+
+// amazonTraceID converts the W3C trace ID to the X-Ray format, e.g. 1-5759e988-bd862e3fe1be46a994272793.
+func amazonTraceID(traceID string) string {
+	return "1-" + traceID[:amazonEpochLength] + "-" + traceID[amazonEpochLength:]
+}
+
+// traceIDFromHeader extracts the trace ID from traceparent header set by TraceContextMiddleware.
+func traceIDFromHeader(h http.Header) string {
+	trace, ok := parseTraceparent(h.Get(Header.Traceparent))
+	if !ok {
+		return ""
+	}
+	return trace.TraceID
+}
+
+func isLowerHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeroHex(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net"
+)
+
+func TestTraceContextMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        http.Header
+		opts          []TraceContextMiddlewareOption
+		wantTraceID   string
+		wantParent    string
+		wantSampled   bool
+		wantState     string
+		wantAmazonHdr bool
+	}{
+		{
+			name: "success:inherited",
+			header: http.Header{
+				Header.Traceparent: {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+				Header.Tracestate:  {"congo=t61rcWkgMzE"},
+			},
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantParent:  "00f067aa0ba902b7",
+			wantSampled: true,
+			wantState:   "congo=t61rcWkgMzE",
+		},
+		{
+			name:        "success:not-sampled",
+			header:      http.Header{Header.Traceparent: {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"}},
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantParent:  "00f067aa0ba902b7",
+		},
+		{
+			name:        "success:future-version",
+			header:      http.Header{Header.Traceparent: {"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"}},
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantParent:  "00f067aa0ba902b7",
+			wantSampled: true,
+		},
+		{
+			name: "success:amazon",
+			header: http.Header{
+				Header.AmazonTraceID: {"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"},
+			},
+			opts:          []TraceContextMiddlewareOption{WithAmazonTraceID()},
+			wantTraceID:   "5759e988bd862e3fe1be46a994272793",
+			wantParent:    "53995c3f42cd8ad8",
+			wantSampled:   true,
+			wantAmazonHdr: true,
+		},
+		{
+			name:        "success:new-trace",
+			wantSampled: true,
+		},
+		{
+			name:        "failure:zero-trace-id",
+			header:      http.Header{Header.Traceparent: {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}},
+			wantSampled: true,
+		},
+		{
+			name:        "failure:uppercase",
+			header:      http.Header{Header.Traceparent: {"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"}},
+			wantSampled: true,
+		},
+		{
+			name:        "failure:amazon-disabled",
+			header:      http.Header{Header.AmazonTraceID: {"Root=1-5759e988-bd862e3fe1be46a994272793"}},
+			wantSampled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got net.Trace
+			h := TraceContextMiddleware(tt.opts...)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got, _ = net.TraceFromCtx(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header = tt.header
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if tt.wantTraceID != "" {
+				assert.Equal(t, tt.wantTraceID, got.TraceID)
+			} else {
+				assert.Len(t, got.TraceID, traceIDLength)
+				assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", got.TraceID)
+			}
+			assert.Len(t, got.SpanID, spanIDLength)
+			assert.NotEqual(t, tt.wantParent, got.SpanID)
+			assert.Equal(t, tt.wantParent, got.ParentSpanID)
+			assert.Equal(t, tt.wantSampled, got.Sampled)
+			assert.Equal(t, tt.wantState, got.State)
+
+			assert.Equal(t, FormatTraceparent(got), rec.Header().Get(Header.Traceparent))
+			assert.Equal(t, tt.wantState, rec.Header().Get(Header.Tracestate))
+			if tt.wantAmazonHdr {
+				assert.Equal(t,
+					"Root=1-5759e988-bd862e3fe1be46a994272793;Parent="+got.SpanID+";Sampled=1",
+					rec.Header().Get(Header.AmazonTraceID),
+				)
+			} else {
+				assert.Empty(t, rec.Header().Get(Header.AmazonTraceID))
+			}
+		})
+	}
+}
+
+func TestTraceContextMiddleware_ErrorResponse(t *testing.T) {
+	h := TraceContextMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = WriteErrorResponse(w, http.StatusBadRequest, WithError(errors.New("invalid")), WithErrorCode("ERR_INVALID"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(Header.Traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t,
+		`{"traceId":"4bf92f3577b34da6a3ce929d0e0e4736","errorCode":"ERR_INVALID"}`,
+		rec.Body.String(),
+	)
+}
@@ -0,0 +1,196 @@
+package tracing
+
+import (
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.strv.io/net"
+	httpx "go.strv.io/net/http"
+	"go.strv.io/net/internal"
+)
+
+// ScopeName is the instrumentation scope name of tracers created by this package.
+const ScopeName = "go.strv.io/net/http/tracing"
+
+const requestIDKey = attribute.Key("http.request.id")
+
+type MiddlewareOptions struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	logger         *slog.Logger
+}
+
+type MiddlewareOption func(*MiddlewareOptions)
+
+// WithTracerProvider sets the provider of the tracer. Default is the global provider (otel.GetTracerProvider).
+func WithTracerProvider(tp trace.TracerProvider) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithPropagator sets the propagator extracting the parent span from the request headers.
+// Default is the global propagator (otel.GetTextMapPropagator).
+func WithPropagator(p propagation.TextMapPropagator) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.propagator = p
+	}
+}
+
+// WithLogger sets a logger for the response writer created by the middleware.
+func WithLogger(l *slog.Logger) MiddlewareOption {
+	return func(o *MiddlewareOptions) {
+		o.logger = l
+	}
+}
+
+// Middleware starts a server span for each request.
+//
+// The parent span is extracted from the request headers using the propagator. If the headers do not contain
+// a parent span, the caller's span of the trace saved by httpx.TraceContextMiddleware is used. The span is named
+// by the HTTP method and the route pattern (e.g. "GET /users/{id}"), so the middleware has to be registered
+// with chi.Router.Use to see the matched route. Attributes are set from RequestData (see Attributes).
+// The error object of the response writer is recorded, and if the status code is >= 500, the span status is set to error.
+//
+// The span context is saved into the context as the trace (replacing the one of httpx.TraceContextMiddleware,
+// including traceparent response header), so LoggingMiddleware and error responses contain the trace and span ID
+// of the exported span.
+// RecoverMiddleware records recovered panics as span events, if it is registered after this middleware.
+func Middleware(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	o := MiddlewareOptions{
+		logger: internal.NewNopLogger(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.tracerProvider == nil {
+		o.tracerProvider = otel.GetTracerProvider()
+	}
+	if o.propagator == nil {
+		o.propagator = otel.GetTextMapPropagator()
+	}
+	tracer := o.tracerProvider.Tracer(ScopeName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := o.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				if t, ok := net.TraceFromCtx(ctx); ok && t.ParentSpanID != "" {
+					ctx = trace.ContextWithRemoteSpanContext(ctx, parentSpanContextFromTrace(t))
+				}
+			}
+			parent := trace.SpanContextFromContext(ctx)
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.URLScheme(scheme(r)),
+					semconv.ServerAddress(r.Host),
+					semconv.UserAgentOriginal(r.UserAgent()),
+				),
+			)
+			defer span.End()
+
+			if span.SpanContext().IsValid() {
+				t := traceFromSpanContext(span.SpanContext(), parent)
+				ctx = net.WithTrace(ctx, t)
+				if w.Header().Get(httpx.Header.Traceparent) != "" {
+					w.Header().Set(httpx.Header.Traceparent, httpx.FormatTraceparent(t))
+				}
+			}
+
+			rw, ok := w.(*httpx.ResponseWriter)
+			if !ok {
+				rw = httpx.NewResponseWriter(w, o.logger)
+			}
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			rd := httpx.RequestData{
+				Path:               r.URL.EscapedPath(),
+				Method:             r.Method,
+				ResponseStatusCode: rw.StatusCode(),
+				RequestID:          net.RequestIDFromCtx(ctx),
+				ClientIP:           net.ClientIPFromCtx(ctx),
+			}
+			span.SetAttributes(Attributes(rd)...)
+
+			if pattern := internal.RoutePattern(r); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+
+			if err := rw.ErrorObject(); err != nil {
+				span.RecordError(err)
+			}
+			if rd.ResponseStatusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rd.ResponseStatusCode))
+			}
+		})
+	}
+}
+
+// Attributes converts RequestData into span attributes following OpenTelemetry semantic conventions.
+// Empty request ID and client IP are omitted.
+func Attributes(rd httpx.RequestData) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(rd.Method),
+		semconv.URLPath(rd.Path),
+		semconv.HTTPResponseStatusCode(rd.ResponseStatusCode),
+	}
+	if rd.RequestID != "" {
+		attrs = append(attrs, requestIDKey.String(rd.RequestID))
+	}
+	if rd.ClientIP != "" {
+		attrs = append(attrs, semconv.ClientAddress(rd.ClientIP))
+	}
+	return attrs
+}
+
+func traceFromSpanContext(sc, parent trace.SpanContext) net.Trace {
+	t := net.Trace{
+		TraceID: sc.TraceID().String(),
+		SpanID:  sc.SpanID().String(),
+		Sampled: sc.IsSampled(),
+		State:   sc.TraceState().String(),
+	}
+	if parent.IsValid() {
+		t.ParentSpanID = parent.SpanID().String()
+	}
+	return t
+}
+
+// parentSpanContextFromTrace converts the caller's span of the trace into a remote span context.
+// The span context is invalid if the trace contains malformed identifiers.
+func parentSpanContextFromTrace(t net.Trace) trace.SpanContext {
+	traceID, _ := trace.TraceIDFromHex(t.TraceID)
+	spanID, _ := trace.SpanIDFromHex(t.ParentSpanID)
+	state, _ := trace.ParseTraceState(t.State)
+	var flags trace.TraceFlags
+	if t.Sampled {
+		flags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		TraceState: state,
+		Remote:     true,
+	})
+}
+
+func scheme(r *http.Request) string {
+	if c, ok := net.ClientFromCtx(r.Context()); ok && c.Scheme != "" {
+		return c.Scheme
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,154 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.strv.io/net"
+	httpx "go.strv.io/net/http"
+	"go.strv.io/net/http/tracing"
+	"go.strv.io/net/internal"
+)
+
+func newRouter(tp trace.TracerProvider, gotTrace *net.Trace) http.Handler {
+	r := chi.NewRouter()
+	r.Use(
+		httpx.RequestIDMiddleware(func(h http.Header) string { return h.Get(httpx.Header.XRequestID) }),
+		tracing.Middleware(tracing.WithTracerProvider(tp), tracing.WithPropagator(propagation.TraceContext{})),
+		httpx.RecoverMiddleware(internal.NewNopLogger()),
+	)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		*gotTrace, _ = net.TraceFromCtx(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Get("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	return r
+}
+
+func attributes(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, a := range s.Attributes {
+		m[a.Key] = a.Value
+	}
+	return m
+}
+
+func TestMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	var gotTrace net.Trace
+	h := newRouter(tp, &gotTrace)
+
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	r.Header.Set(httpx.Header.XRequestID, "request-1")
+	r.Header.Set(httpx.Header.Traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /users/{id}", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	attrs := attributes(span)
+	assert.Equal(t, "GET", attrs["http.request.method"].AsString())
+	assert.Equal(t, "/users/42", attrs["url.path"].AsString())
+	assert.Equal(t, "/users/{id}", attrs["http.route"].AsString())
+	assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	assert.Equal(t, "request-1", attrs["http.request.id"].AsString())
+
+	assert.Equal(t, net.Trace{
+		TraceID:      "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:       span.SpanContext.SpanID().String(),
+		ParentSpanID: "00f067aa0ba902b7",
+		Sampled:      true,
+	}, gotTrace)
+}
+
+func TestMiddleware_TraceContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		wantParent  string
+	}{
+		{
+			name:        "success:incoming-trace",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantParent:  "00f067aa0ba902b7",
+		},
+		{
+			name: "success:new-trace",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			var gotTrace net.Trace
+			r := chi.NewRouter()
+			r.Use(
+				httpx.TraceContextMiddleware(),
+				tracing.Middleware(tracing.WithTracerProvider(tp), tracing.WithPropagator(propagation.Baggage{})),
+			)
+			r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+				gotTrace, _ = net.TraceFromCtx(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+			if tt.traceparent != "" {
+				req.Header.Set(httpx.Header.Traceparent, tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, span.SpanContext.TraceID().String(), gotTrace.TraceID)
+			assert.Equal(t, span.SpanContext.SpanID().String(), gotTrace.SpanID)
+			assert.Equal(t, tt.wantParent, gotTrace.ParentSpanID)
+			if tt.wantParent != "" {
+				assert.Equal(t, tt.wantParent, span.Parent.SpanID().String())
+			} else {
+				assert.False(t, span.Parent.IsValid())
+			}
+			assert.Equal(t, httpx.FormatTraceparent(gotTrace), rec.Header().Get(httpx.Header.Traceparent))
+		})
+	}
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	h := newRouter(tp, &net.Trace{})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /panic", span.Name)
+	assert.Equal(t, codes.Error, span.Status.Code)
+	require.Len(t, span.Events, 1)
+	assert.Equal(t, "exception", span.Events[0].Name)
+	assert.Contains(t, span.Events[0].Attributes, attribute.String("exception.message", "boom"))
+}
@@ -0,0 +1,203 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"go.strv.io/net/http/param"
+)
+
+const errCodeValidation = "ERR_VALIDATION"
+
+// Locations of violations.
+const (
+	ViolationInBody  = "body"
+	ViolationInQuery = "query"
+	ViolationInPath  = "path"
+)
+
+// Codes of violations built by ViolationsFromError.
+const (
+	ViolationCodeInvalidSyntax = "invalid_syntax"
+	ViolationCodeInvalidType   = "invalid_type"
+	ViolationCodeInvalidValue  = "invalid_value"
+	ViolationCodeUnknownField  = "unknown_field"
+)
+
+// jsonUnknownFieldPrefix is the prefix of the error returned by json.Decoder with DisallowUnknownFields.
+const jsonUnknownFieldPrefix = `json: unknown field "`
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Violation describes why a single field or parameter of the request is invalid.
+type Violation struct {
+	// In is the location of the invalid value, e.g. ViolationInBody or ViolationInQuery.
+	In string `json:"in" xml:"in" yaml:"in"`
+	// Pointer is a JSON Pointer (RFC 6901) to the invalid field of the body, e.g. /items/0/name.
+	Pointer string `json:"pointer,omitempty" xml:"pointer,omitempty" yaml:"pointer,omitempty"`
+	// Parameter is the name of the invalid query or path parameter.
+	Parameter string `json:"parameter,omitempty" xml:"parameter,omitempty" yaml:"parameter,omitempty"`
+	// Code is a machine code of the violation, e.g. required or too_long.
+	Code    string `json:"code" xml:"code" yaml:"code"`
+	Message string `json:"message,omitempty" xml:"message,omitempty" yaml:"message,omitempty"`
+	// Params are parameters of the violated constraint, e.g. {"max": 100}.
+	Params map[string]any `json:"params,omitempty" xml:"-" yaml:"params,omitempty"`
+}
+
+// ValidationError is an error collecting violations, e.g. in a custom validator:
+//
+//	func (i CreateUserInput) Validate() error {
+//		var v httpx.ValidationError
+//		if i.Name == "" {
+//			v.Add(httpx.Violation{In: httpx.ViolationInBody, Pointer: "/name", Code: "required", Message: "name is required"})
+//		}
+//		return v.Err()
+//	}
+//
+// Errors of other validation libraries can be converted to it, so they are written the same way.
+// It is written as errorData of error responses by WithViolations.
+type ValidationError struct {
+	Violations []Violation `json:"violations" xml:"violation" yaml:"violations"`
+}
+
+// Add appends violations.
+func (e *ValidationError) Add(v ...Violation) {
+	e.Violations = append(e.Violations, v...)
+}
+
+// Err returns the error if it contains any violations, nil otherwise.
+func (e *ValidationError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		field := v.Pointer
+		if field == "" {
+			field = v.Parameter
+		}
+		messages = append(messages, fmt.Sprintf("%s %s: %s", v.In, field, v.Code))
+	}
+	return "validation failed: " + strings.Join(messages, ", ")
+}
+
+// WithViolations writes the violations as errorData of the error response (see ValidationError)
+// with ERR_VALIDATION error code.
+func WithViolations(violations ...Violation) ErrorResponseOption {
+	return func(o *ErrorResponseOptions) {
+		o.ErrCode = errCodeValidation
+		o.ErrData = ValidationError{Violations: violations}
+	}
+}
+
+// ViolationsFromError returns violations described by err, false if err does not describe invalid input.
+// Supported errors are:
+//   - ValidationError, e.g. returned by a custom validator
+//   - param.ParseError of invalid path and query parameters
+//   - errors of decoding JSON body: syntax errors, type mismatches and unknown fields
+//     (returned if json.Decoder.DisallowUnknownFields is used)
+func ViolationsFromError(err error) ([]Violation, bool) {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Violations, true
+	}
+	var parseErr *param.ParseError
+	if errors.As(err, &parseErr) {
+		return []Violation{{
+			In:        parseErr.In,
+			Parameter: parseErr.Name,
+			Code:      ViolationCodeInvalidValue,
+			Message:   "invalid value",
+		}}, true
+	}
+	return violationsFromJSONError(err)
+}
+
+func violationsFromJSONError(err error) ([]Violation, bool) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return []Violation{{
+			In:      ViolationInBody,
+			Code:    ViolationCodeInvalidSyntax,
+			Message: "invalid JSON",
+			Params:  map[string]any{"offset": syntaxErr.Offset},
+		}}, true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return []Violation{{
+			In:      ViolationInBody,
+			Code:    ViolationCodeInvalidSyntax,
+			Message: "unexpected end of body",
+		}}, true
+	case errors.As(err, &typeErr):
+		expected := jsonTypeName(typeErr.Type)
+		return []Violation{{
+			In:      ViolationInBody,
+			Pointer: jsonPointer(strings.Split(typeErr.Field, ".")...),
+			Code:    ViolationCodeInvalidType,
+			Message: "expected " + expected,
+			Params:  map[string]any{"expected": expected, "actual": typeErr.Value},
+		}}, true
+	}
+	// json.Decoder does not export the error type of unknown fields.
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if field, ok := strings.CutPrefix(e.Error(), jsonUnknownFieldPrefix); ok {
+			field = strings.TrimSuffix(field, `"`)
+			return []Violation{{
+				In:      ViolationInBody,
+				Pointer: jsonPointer(strings.Split(field, ".")...),
+				Code:    ViolationCodeUnknownField,
+				Message: "unknown field",
+			}}, true
+		}
+	}
+	return nil, false
+}
+
+// jsonPointer returns a JSON Pointer (RFC 6901) of the path segments.
+func jsonPointer(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if s == "" {
+			continue
+		}
+		b.WriteByte('/')
+		b.WriteString(jsonPointerEscaper.Replace(s))
+	}
+	return b.String()
+}
+
+// jsonTypeName returns the name of the JSON type the Go type is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	if t == nil {
+		return "value"
+	}
+	//nolint:exhaustive
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return "value"
+	}
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net/http/param"
+)
+
+func TestViolationsFromError(t *testing.T) {
+	type address struct {
+		Zip int `json:"zip"`
+	}
+	type user struct {
+		Name    string  `json:"name"`
+		Address address `json:"address"`
+	}
+	decode := func(body string) error {
+		d := json.NewDecoder(strings.NewReader(body))
+		d.DisallowUnknownFields()
+		return d.Decode(&user{})
+	}
+	parse := func(query string) error {
+		var input struct {
+			Page int `param:"query=page"`
+		}
+		return param.DefaultParser().Parse(httptest.NewRequest(http.MethodGet, "/users?"+query, nil), &input)
+	}
+	var validationErr ValidationError
+	validationErr.Add(Violation{In: ViolationInBody, Pointer: "/name", Code: "required"})
+
+	tests := []struct {
+		name string
+		err  error
+		want []Violation
+	}{
+		{
+			name: "success:validation-error",
+			err:  fmt.Errorf("validating: %w", validationErr.Err()),
+			want: []Violation{{In: ViolationInBody, Pointer: "/name", Code: "required"}},
+		},
+		{
+			name: "success:param",
+			err:  parse("page=first"),
+			want: []Violation{{In: ViolationInQuery, Parameter: "page", Code: ViolationCodeInvalidValue, Message: "invalid value"}},
+		},
+		{
+			name: "success:syntax",
+			err:  decode(`{"name":}`),
+			want: []Violation{{
+				In:      ViolationInBody,
+				Code:    ViolationCodeInvalidSyntax,
+				Message: "invalid JSON",
+				Params:  map[string]any{"offset": int64(9)},
+			}},
+		},
+		{
+			name: "success:empty-body",
+			err:  decode(""),
+			want: []Violation{{In: ViolationInBody, Code: ViolationCodeInvalidSyntax, Message: "unexpected end of body"}},
+		},
+		{
+			name: "success:type",
+			err:  decode(`{"address":{"zip":"110 00"}}`),
+			want: []Violation{{
+				In:      ViolationInBody,
+				Pointer: "/address/zip",
+				Code:    ViolationCodeInvalidType,
+				Message: "expected number",
+				Params:  map[string]any{"expected": "number", "actual": "string"},
+			}},
+		},
+		{
+			name: "success:unknown-field",
+			err:  decode(`{"nickname":"al"}`),
+			want: []Violation{{In: ViolationInBody, Pointer: "/nickname", Code: ViolationCodeUnknownField, Message: "unknown field"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ViolationsFromError(tt.err)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("failure:other-error", func(t *testing.T) {
+		_, ok := ViolationsFromError(errors.New("connection refused"))
+		assert.False(t, ok)
+	})
+}
+
+func TestValidationError_Err(t *testing.T) {
+	var v ValidationError
+	require.NoError(t, v.Err())
+
+	v.Add(Violation{In: ViolationInQuery, Parameter: "page", Code: "min", Params: map[string]any{"min": 1}})
+	assert.EqualError(t, v.Err(), "validation failed: query page: min")
+}
+
+func TestWriteErrorResponse_WithViolations(t *testing.T) {
+	w := httptest.NewRecorder()
+	require.NoError(t, WriteErrorResponse(w, http.StatusBadRequest, WithViolations(Violation{
+		In:      ViolationInBody,
+		Pointer: "/name",
+		Code:    "too_long",
+		Message: "name is too long",
+		Params:  map[string]any{"max": 100},
+	})))
+
+	assert.JSONEq(t, `{
+		"errorCode": "ERR_VALIDATION",
+		"errorData": {"violations": [{"in": "body", "pointer": "/name", "code": "too_long", "message": "name is too long", "params": {"max": 100}}]}
+	}`, w.Body.String())
+}
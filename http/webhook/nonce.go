@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NonceStore remembers nonces of accepted webhooks to reject replays.
+type NonceStore interface {
+	// Store records the nonce for ttl. It returns false if the nonce is already stored and not yet expired.
+	// Implementations must be safe for concurrent use and the check and the store must be atomic.
+	Store(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceStore is an in-memory NonceStore.
+// It is suitable only for a single instance of a service, use a shared store (e.g. Redis SET NX) otherwise.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	now    func() time.Time
+}
+
+// NewMemoryNonceStore creates an empty MemoryNonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{
+		nonces: map[string]time.Time{},
+		now:    time.Now,
+	}
+}
+
+// Store implements NonceStore. Expired nonces are removed on each call.
+func (s *MemoryNonceStore) Store(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for n, expiresAt := range s.nonces {
+		if !now.Before(expiresAt) {
+			delete(s.nonces, n)
+		}
+	}
+
+	if _, ok := s.nonces[nonce]; ok {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Encoding defines how the signature digest is encoded in the header.
+type Encoding int
+
+const (
+	// EncodingHex expects a lowercase or uppercase hexadecimal digest.
+	EncodingHex Encoding = iota
+	// EncodingBase64 expects a standard base64 encoded digest.
+	EncodingBase64
+)
+
+// decode decodes the digest according to the encoding.
+func (e Encoding) decode(s string) ([]byte, error) {
+	switch e {
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(s)
+	case EncodingHex:
+		return hex.DecodeString(s)
+	default:
+		return nil, errors.New("unknown signature encoding")
+	}
+}
+
+// SignatureParserFunc extracts the timestamp and all signatures from the request headers.
+// The returned timestamp is empty if the scheme does not use one.
+// Signatures are returned still encoded, without any prefix.
+type SignatureParserFunc func(h http.Header) (timestamp string, signatures []string, err error)
+
+// PayloadFunc builds the message that is signed by the provider from the timestamp and the raw request body.
+type PayloadFunc func(timestamp string, body []byte) []byte
+
+// Scheme describes how a provider signs its webhooks.
+// Presets are available for the most common providers (see StripeScheme, GitHubScheme and SlackScheme),
+// a custom Scheme can be defined for any other provider that uses HMAC-SHA256.
+type Scheme struct {
+	// ParseSignature extracts the timestamp and signatures from the request.
+	// If not set, HeaderSignatureParser with SignatureHeader, SignaturePrefix and TimestampHeader is used.
+	ParseSignature SignatureParserFunc
+
+	// SignatureHeader is the header containing the signature.
+	SignatureHeader string
+
+	// SignaturePrefix is trimmed from the signature header value (e.g. "sha256=").
+	SignaturePrefix string
+
+	// TimestampHeader is the header containing the unix timestamp of the webhook.
+	// If empty, the scheme does not use a timestamp and the timestamp tolerance is not enforced.
+	TimestampHeader string
+
+	// Payload builds the signed message. If not set, only the raw body is signed.
+	Payload PayloadFunc
+
+	// Encoding of the signature digest.
+	Encoding Encoding
+}
+
+// HeaderSignatureParser returns a SignatureParserFunc reading a single signature from signatureHeader
+// (with the prefix trimmed) and an optional timestamp from timestampHeader.
+func HeaderSignatureParser(signatureHeader, prefix, timestampHeader string) SignatureParserFunc {
+	return func(h http.Header) (string, []string, error) {
+		signature := h.Get(signatureHeader)
+		if signature == "" {
+			return "", nil, ErrMissingSignature
+		}
+		signature, ok := strings.CutPrefix(signature, prefix)
+		if !ok {
+			return "", nil, ErrMissingSignature
+		}
+		var timestamp string
+		if timestampHeader != "" {
+			if timestamp = h.Get(timestampHeader); timestamp == "" {
+				return "", nil, ErrMissingTimestamp
+			}
+		}
+		return timestamp, []string{signature}, nil
+	}
+}
+
+// StripeScheme returns a Scheme for Stripe-style webhooks.
+//
+// The header has a format `Stripe-Signature: t=<unix>,v1=<hex>[,v1=<hex>...]` and the signed payload is `<unix>.<body>`.
+func StripeScheme() Scheme {
+	return Scheme{
+		ParseSignature: KeyValueSignatureParser("Stripe-Signature", "t", "v1"),
+		Payload:        joinPayload("."),
+		Encoding:       EncodingHex,
+	}
+}
+
+// GitHubScheme returns a Scheme for GitHub-style webhooks.
+//
+// The header has a format `X-Hub-Signature-256: sha256=<hex>` and only the body is signed.
+// GitHub does not send a timestamp, so webhooks are rejected as replayed only within the nonce TTL.
+func GitHubScheme() Scheme {
+	return Scheme{
+		SignatureHeader: "X-Hub-Signature-256",
+		SignaturePrefix: "sha256=",
+		Encoding:        EncodingHex,
+	}
+}
+
+// SlackScheme returns a Scheme for Slack-style webhooks.
+//
+// The signature header has a format `X-Slack-Signature: v0=<hex>`, timestamp is sent in `X-Slack-Request-Timestamp`
+// and the signed payload is `v0:<unix>:<body>`.
+func SlackScheme() Scheme {
+	return Scheme{
+		SignatureHeader: "X-Slack-Signature",
+		SignaturePrefix: "v0=",
+		TimestampHeader: "X-Slack-Request-Timestamp",
+		Payload: func(timestamp string, body []byte) []byte {
+			return joinPayload(":")("v0:"+timestamp, body)
+		},
+		Encoding: EncodingHex,
+	}
+}
+
+// KeyValueSignatureParser returns a SignatureParserFunc for headers with comma separated key=value pairs
+// (e.g. `t=1492774577,v1=5257a8,v1=0f3a9c`). All values of signatureKey are returned as signatures,
+// so a provider can send multiple signatures during its own secret rotation.
+func KeyValueSignatureParser(header, timestampKey, signatureKey string) SignatureParserFunc {
+	return func(h http.Header) (string, []string, error) {
+		value := h.Get(header)
+		if value == "" {
+			return "", nil, ErrMissingSignature
+		}
+		var (
+			timestamp  string
+			signatures []string
+		)
+		for _, pair := range strings.Split(value, ",") {
+			k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				continue
+			}
+			switch k {
+			case timestampKey:
+				timestamp = v
+			case signatureKey:
+				signatures = append(signatures, v)
+			}
+		}
+		if len(signatures) == 0 {
+			return "", nil, ErrMissingSignature
+		}
+		if timestampKey != "" && timestamp == "" {
+			return "", nil, ErrMissingTimestamp
+		}
+		return timestamp, signatures, nil
+	}
+}
+
+func joinPayload(separator string) PayloadFunc {
+	return func(timestamp string, body []byte) []byte {
+		payload := make([]byte, 0, len(timestamp)+len(separator)+len(body))
+		payload = append(payload, timestamp...)
+		payload = append(payload, separator...)
+		return append(payload, body...)
+	}
+}
+
+func (s Scheme) parser() SignatureParserFunc {
+	if s.ParseSignature != nil {
+		return s.ParseSignature
+	}
+	return HeaderSignatureParser(s.SignatureHeader, s.SignaturePrefix, s.TimestampHeader)
+}
+
+func (s Scheme) payload(timestamp string, body []byte) []byte {
+	if s.Payload == nil {
+		return body
+	}
+	return s.Payload(timestamp, body)
+}
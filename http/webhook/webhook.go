@@ -0,0 +1,239 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	httpx "go.strv.io/net/http"
+)
+
+const (
+	defaultTolerance   = 5 * time.Minute
+	defaultNonceTTL    = 24 * time.Hour
+	defaultMaxBodySize = 1 << 20
+
+	errCodeInvalidSignature = "ERR_INVALID_WEBHOOK_SIGNATURE"
+)
+
+var (
+	// ErrMissingSignature is returned when the request does not contain a signature.
+	ErrMissingSignature = errors.New("missing webhook signature")
+	// ErrMissingTimestamp is returned when the scheme requires a timestamp, but the request does not contain one.
+	ErrMissingTimestamp = errors.New("missing webhook timestamp")
+	// ErrInvalidTimestamp is returned when the timestamp cannot be parsed as unix time.
+	ErrInvalidTimestamp = errors.New("invalid webhook timestamp")
+	// ErrTimestampOutOfTolerance is returned when the timestamp is too far from the current time.
+	ErrTimestampOutOfTolerance = errors.New("webhook timestamp out of tolerance")
+	// ErrInvalidSignature is returned when none of the signatures matches any of the secrets.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrReplayed is returned when the same webhook has already been accepted.
+	ErrReplayed = errors.New("webhook replayed")
+)
+
+// ErrorHandlerFunc is called by the Verifier middleware when the verification fails.
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+// Verifier verifies HMAC-SHA256 signatures of incoming webhooks.
+type Verifier struct {
+	scheme       Scheme
+	secrets      [][]byte
+	tolerance    time.Duration
+	nonceStore   NonceStore
+	nonceTTL     time.Duration
+	maxBodySize  int64
+	errorHandler ErrorHandlerFunc
+	now          func() time.Time
+}
+
+// VerifierOption configures the Verifier.
+type VerifierOption func(*Verifier)
+
+// NewVerifier creates a Verifier for the given scheme.
+// More secrets can be passed during a key rotation, the signature is valid if it matches any of them.
+//
+// By default, the timestamp tolerance is 5 minutes, no replay protection is applied,
+// the body is limited to 1 MiB and failures are answered with 401 Unauthorized.
+func NewVerifier(scheme Scheme, secrets [][]byte, opts ...VerifierOption) *Verifier {
+	v := &Verifier{
+		scheme:       scheme,
+		secrets:      secrets,
+		tolerance:    defaultTolerance,
+		maxBodySize:  defaultMaxBodySize,
+		errorHandler: UnauthorizedErrorHandle,
+		now:          time.Now,
+	}
+	for _, o := range opts {
+		o(v)
+	}
+	return v
+}
+
+// WithTolerance sets the maximum allowed difference between the webhook timestamp and the current time.
+// Tolerance less or equal to 0 disables the check.
+func WithTolerance(d time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.tolerance = d
+	}
+}
+
+// WithNonceStore enables the replay protection. The signature of every accepted webhook is recorded in the store
+// for ttl, and the same webhook is rejected with ErrReplayed during that time. The decoded signature is used as a nonce,
+// as unsigned headers (e.g. delivery identifiers) can be changed by an attacker replaying the webhook.
+// If ttl is less or equal to 0, twice the tolerance (or 24 hours for schemes without timestamp) is used.
+func WithNonceStore(s NonceStore, ttl time.Duration) VerifierOption {
+	return func(v *Verifier) {
+		v.nonceStore = s
+		v.nonceTTL = ttl
+	}
+}
+
+// WithMaxBodySize limits the number of bytes read from the request body.
+func WithMaxBodySize(n int64) VerifierOption {
+	return func(v *Verifier) {
+		v.maxBodySize = n
+	}
+}
+
+// WithErrorHandler sets a function that writes the response when the verification fails.
+func WithErrorHandler(f ErrorHandlerFunc) VerifierOption {
+	return func(v *Verifier) {
+		v.errorHandler = f
+	}
+}
+
+// Verify checks the signature of the request.
+// The body is read whole and replaced with an in-memory copy, so it can be read again by the next handler
+// (e.g. signature.UnmarshalRequestBody).
+func (v *Verifier) Verify(r *http.Request) error {
+	timestamp, signatures, err := v.scheme.parser()(r.Header)
+	if err != nil {
+		return err
+	}
+
+	if timestamp != "" {
+		if err = v.checkTimestamp(timestamp); err != nil {
+			return err
+		}
+	}
+
+	body, err := readBody(r, v.maxBodySize)
+	if err != nil {
+		return fmt.Errorf("reading body: %w", err)
+	}
+
+	mac, ok := v.match(v.scheme.payload(timestamp, body), signatures)
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	if v.nonceStore == nil {
+		return nil
+	}
+	// The decoded MAC is used as a nonce, as the signature can be encoded in more ways (e.g. uppercase hex).
+	fresh, err := v.nonceStore.Store(r.Context(), hex.EncodeToString(mac), v.replayTTL(timestamp != ""))
+	if err != nil {
+		return fmt.Errorf("storing nonce: %w", err)
+	}
+	if !fresh {
+		return ErrReplayed
+	}
+	return nil
+}
+
+// Middleware returns a middleware that calls the next handler only if the request is correctly signed.
+func (v *Verifier) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := v.Verify(r); err != nil {
+				v.errorHandler(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnauthorizedErrorHandle is a default ErrorHandlerFunc.
+// It writes 401 Unauthorized http status code with ERR_INVALID_WEBHOOK_SIGNATURE error code.
+func UnauthorizedErrorHandle(w http.ResponseWriter, _ *http.Request, err error) {
+	_ = httpx.WriteErrorResponse(
+		w,
+		http.StatusUnauthorized,
+		httpx.WithError(err),
+		httpx.WithErrorCode(errCodeInvalidSignature),
+	)
+}
+
+func (v *Verifier) checkTimestamp(timestamp string) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTimestamp, err)
+	}
+	if v.tolerance <= 0 {
+		return nil
+	}
+	diff := v.now().Sub(time.Unix(unix, 0))
+	if diff > v.tolerance || diff < -v.tolerance {
+		return ErrTimestampOutOfTolerance
+	}
+	return nil
+}
+
+// match returns the decoded MAC of the first of signatures that is valid for any of the secrets.
+func (v *Verifier) match(payload []byte, signatures []string) ([]byte, bool) {
+	for _, secret := range v.secrets {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(payload)
+		expected := mac.Sum(nil)
+		for _, signature := range signatures {
+			decoded, err := v.scheme.Encoding.decode(signature)
+			if err != nil {
+				continue
+			}
+			if hmac.Equal(expected, decoded) {
+				return decoded, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// replayTTL returns how long the nonce is remembered. Webhooks with timestamp are rejected after the tolerance anyway,
+// so it is enough to remember them for the tolerance in both directions.
+func (v *Verifier) replayTTL(hasTimestamp bool) time.Duration {
+	switch {
+	case v.nonceTTL > 0:
+		return v.nonceTTL
+	case hasTimestamp && v.tolerance > 0:
+		return 2 * v.tolerance
+	default:
+		return defaultNonceTTL
+	}
+}
+
+func readBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("body exceeds %d bytes", limit)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
@@ -0,0 +1,205 @@
+package webhook_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.strv.io/net/http/webhook"
+)
+
+const body = `{"event":"payment.succeeded"}`
+
+var (
+	secret    = []byte("current-secret")
+	oldSecret = []byte("old-secret")
+)
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newRequest(headers map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	return r
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name    string
+		scheme  webhook.Scheme
+		secrets [][]byte
+		headers map[string]string
+		wantErr error
+	}{
+		{
+			name:    "success:stripe",
+			scheme:  webhook.StripeScheme(),
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"Stripe-Signature": "t=" + now + ",v1=" + sign(secret, now+"."+body),
+			},
+		},
+		{
+			name:    "success:stripe-multiple-signatures",
+			scheme:  webhook.StripeScheme(),
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"Stripe-Signature": "t=" + now + ",v1=" + sign(oldSecret, now+"."+body) + ",v1=" + sign(secret, now+"."+body),
+			},
+		},
+		{
+			name:    "success:github",
+			scheme:  webhook.GitHubScheme(),
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + sign(secret, body),
+			},
+		},
+		{
+			name:    "success:slack",
+			scheme:  webhook.SlackScheme(),
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"X-Slack-Signature":         "v0=" + sign(secret, "v0:"+now+":"+body),
+				"X-Slack-Request-Timestamp": now,
+			},
+		},
+		{
+			name: "success:custom",
+			scheme: webhook.Scheme{
+				SignatureHeader: "X-Signature",
+				TimestampHeader: "X-Timestamp",
+				Payload: func(timestamp string, body []byte) []byte {
+					return []byte(timestamp + "|" + string(body))
+				},
+			},
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"X-Signature": sign(secret, now+"|"+body),
+				"X-Timestamp": now,
+			},
+		},
+		{
+			name:    "success:rotated-secret",
+			scheme:  webhook.GitHubScheme(),
+			secrets: [][]byte{secret, oldSecret},
+			headers: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + sign(oldSecret, body),
+			},
+		},
+		{
+			name:    "failure:unknown-secret",
+			scheme:  webhook.GitHubScheme(),
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + sign(oldSecret, body),
+			},
+			wantErr: webhook.ErrInvalidSignature,
+		},
+		{
+			name:    "failure:missing-signature",
+			scheme:  webhook.GitHubScheme(),
+			secrets: [][]byte{secret},
+			wantErr: webhook.ErrMissingSignature,
+		},
+		{
+			name:    "failure:missing-timestamp",
+			scheme:  webhook.SlackScheme(),
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"X-Slack-Signature": "v0=" + sign(secret, "v0:"+now+":"+body),
+			},
+			wantErr: webhook.ErrMissingTimestamp,
+		},
+		{
+			name:    "failure:timestamp-out-of-tolerance",
+			scheme:  webhook.StripeScheme(),
+			secrets: [][]byte{secret},
+			headers: map[string]string{
+				"Stripe-Signature": "t=" + old + ",v1=" + sign(secret, old+"."+body),
+			},
+			wantErr: webhook.ErrTimestampOutOfTolerance,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := webhook.NewVerifier(tt.scheme, tt.secrets)
+			err := v.Verify(newRequest(tt.headers))
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestVerifier_Replay(t *testing.T) {
+	v := webhook.NewVerifier(
+		webhook.GitHubScheme(),
+		[][]byte{secret},
+		webhook.WithNonceStore(webhook.NewMemoryNonceStore(), 0),
+	)
+	signature := sign(secret, body)
+
+	require.NoError(t, v.Verify(newRequest(map[string]string{"X-Hub-Signature-256": "sha256=" + signature})))
+	err := v.Verify(newRequest(map[string]string{"X-Hub-Signature-256": "sha256=" + strings.ToUpper(signature)}))
+	require.ErrorIs(t, err, webhook.ErrReplayed)
+}
+
+func TestVerifier_Middleware(t *testing.T) {
+	v := webhook.NewVerifier(
+		webhook.GitHubScheme(),
+		[][]byte{secret},
+		webhook.WithNonceStore(webhook.NewMemoryNonceStore(), 0),
+	)
+
+	var received []string
+	h := v.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = append(received, string(b))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	headers := map[string]string{
+		"X-Hub-Signature-256": "sha256=" + sign(secret, body),
+		"X-GitHub-Delivery":   "delivery-1",
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(headers))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, []string{body}, received)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(headers))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.JSONEq(t, `{"errorCode":"ERR_INVALID_WEBHOOK_SIGNATURE"}`, rec.Body.String())
+	assert.Len(t, received, 1)
+
+	headers["X-GitHub-Delivery"] = "delivery-2"
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newRequest(headers))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Len(t, received, 1)
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"go.strv.io/net"
+)
+
+// RoutePattern returns the route pattern saved by RoutePatternMiddleware or matched by chi router,
+// empty if the request is not routed by chi.
+func RoutePattern(r *http.Request) string {
+	if pattern := net.RoutePatternFromCtx(r.Context()); pattern != "" {
+		return pattern
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// CountingReadCloser counts bytes read from the request body.
+type CountingReadCloser struct {
+	io.ReadCloser
+	N int64
+}
+
+func (c *CountingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.N += int64(n)
+	return n, err
+}
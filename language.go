@@ -0,0 +1,18 @@
+package net
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+)
+
+// WithLanguage saves the language negotiated for the response into the context.
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, contextKey.language, tag)
+}
+
+// LanguageFromCtx extracts the language negotiated for the response from the context.
+func LanguageFromCtx(ctx context.Context) (language.Tag, bool) {
+	tag, ok := ctx.Value(contextKey.language).(language.Tag)
+	return tag, ok
+}
@@ -0,0 +1,155 @@
+package net
+
+import (
+	"context"
+	"log/slog"
+)
+
+const (
+	requestIDLogKey       = "request_id"
+	parentRequestIDLogKey = "parent_request_id"
+	traceIDLogKey         = "trace_id"
+	spanIDLogKey          = "span_id"
+	principalLogKey       = "principal"
+	routeLogKey           = "route"
+)
+
+// ContextAttrFunc returns attributes extracted from the context, e.g. a tenant saved by an application middleware.
+type ContextAttrFunc func(ctx context.Context) []slog.Attr
+
+type ContextHandlerOptions struct {
+	attrFuncs []ContextAttrFunc
+}
+
+type ContextHandlerOption func(*ContextHandlerOptions)
+
+// WithContextAttrs registers functions adding custom attributes extracted from the context of each record.
+func WithContextAttrs(f ...ContextAttrFunc) ContextHandlerOption {
+	return func(o *ContextHandlerOptions) {
+		o.attrFuncs = append(o.attrFuncs, f...)
+	}
+}
+
+// WithContextValue logs the value saved in the context under ctxKey as the attribute with the key.
+// Nothing is logged if the context does not contain the value.
+func WithContextValue(key string, ctxKey any) ContextHandlerOption {
+	return WithContextAttrs(func(ctx context.Context) []slog.Attr {
+		v := ctx.Value(ctxKey)
+		if v == nil {
+			return nil
+		}
+		return []slog.Attr{slog.Any(key, v)}
+	})
+}
+
+// ContextHandler is a slog.Handler adding request-scoped values from the context of each record:
+// request_id, parent_request_id, trace_id and span_id, principal, route, and attributes registered
+// by WithContextAttrs or WithContextValue. Records have to be logged with a context, e.g. slog.InfoContext(r.Context(), ...), to be correlated.
+//
+// Values missing in the context are omitted. An attribute is not added if the logger or the record already
+// contains a top-level attribute with the same key, e.g. request_id logged by http.RecoverMiddleware.
+// If the logger has an open group (see slog.Logger.WithGroup), the attributes are added to the group.
+type ContextHandler struct {
+	next      slog.Handler
+	attrFuncs []ContextAttrFunc
+	// keys are top-level keys of attributes added by WithAttrs.
+	keys      map[string]struct{}
+	groupOpen bool
+}
+
+// NewContextHandler wraps the handler to add request-scoped attributes, e.g.:
+//
+//	logger := slog.New(net.NewContextHandler(slog.NewJSONHandler(os.Stdout, nil)))
+func NewContextHandler(h slog.Handler, opts ...ContextHandlerOption) *ContextHandler {
+	o := ContextHandlerOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &ContextHandler{
+		next:      h,
+		attrFuncs: o.attrFuncs,
+	}
+}
+
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		return h.next.Handle(ctx, r)
+	}
+	attrs := h.contextAttrs(ctx)
+	if len(attrs) == 0 {
+		return h.next.Handle(ctx, r)
+	}
+
+	var recordKeys map[string]struct{}
+	if !h.groupOpen {
+		recordKeys = make(map[string]struct{}, r.NumAttrs())
+		r.Attrs(func(a slog.Attr) bool {
+			recordKeys[a.Key] = struct{}{}
+			return true
+		})
+	}
+	r = r.Clone()
+	for _, a := range attrs {
+		if _, ok := h.keys[a.Key]; ok {
+			continue
+		}
+		if _, ok := recordKeys[a.Key]; ok {
+			continue
+		}
+		r.AddAttrs(a)
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	if !h.groupOpen {
+		c.keys = make(map[string]struct{}, len(h.keys)+len(attrs))
+		for k := range h.keys {
+			c.keys[k] = struct{}{}
+		}
+		for _, a := range attrs {
+			c.keys[a.Key] = struct{}{}
+		}
+	}
+	return &c
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.next = h.next.WithGroup(name)
+	c.groupOpen = true
+	c.keys = nil
+	return &c
+}
+
+func (h *ContextHandler) contextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if requestID := RequestIDFromCtx(ctx); requestID != "" {
+		attrs = append(attrs, slog.String(requestIDLogKey, requestID))
+	}
+	if parentRequestID := ParentRequestIDFromCtx(ctx); parentRequestID != "" {
+		attrs = append(attrs, slog.String(parentRequestIDLogKey, parentRequestID))
+	}
+	if trace, ok := TraceFromCtx(ctx); ok && trace.TraceID != "" {
+		attrs = append(attrs, slog.String(traceIDLogKey, trace.TraceID), slog.String(spanIDLogKey, trace.SpanID))
+	}
+	if principal := PrincipalFromCtx(ctx); principal != "" {
+		attrs = append(attrs, slog.String(principalLogKey, principal))
+	}
+	if route := RoutePatternFromCtx(ctx); route != "" {
+		attrs = append(attrs, slog.String(routeLogKey, route))
+	}
+	for _, f := range h.attrFuncs {
+		attrs = append(attrs, f(ctx)...)
+	}
+	return attrs
+}
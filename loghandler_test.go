@@ -0,0 +1,122 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKeyTenant struct{}
+
+func TestContextHandler(t *testing.T) {
+	requestCtx := WithRequestID(context.Background(), "request-1")
+	requestCtx = WithTrace(requestCtx, Trace{TraceID: "5759e988bd862e3fe1be46a994272793", SpanID: "53995c3f42cd8ad8"})
+	requestCtx = WithPrincipal(requestCtx, "alice")
+	requestCtx = context.WithValue(requestCtx, ctxKeyTenant{}, "acme")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		opts []ContextHandlerOption
+		log  func(ctx context.Context, l *slog.Logger)
+		want map[string]any
+	}{
+		{
+			name: "success:request-values",
+			ctx:  requestCtx,
+			log: func(ctx context.Context, l *slog.Logger) {
+				l.InfoContext(ctx, "msg", "key", "value")
+			},
+			want: map[string]any{
+				"key":        "value",
+				"request_id": "request-1",
+				"trace_id":   "5759e988bd862e3fe1be46a994272793",
+				"span_id":    "53995c3f42cd8ad8",
+				"principal":  "alice",
+			},
+		},
+		{
+			name: "success:registered-values",
+			ctx:  requestCtx,
+			opts: []ContextHandlerOption{
+				WithContextValue("tenant", ctxKeyTenant{}),
+				WithContextValue("missing", struct{}{}),
+				WithContextAttrs(func(ctx context.Context) []slog.Attr {
+					return []slog.Attr{slog.Bool("authenticated", PrincipalFromCtx(ctx) != "")}
+				}),
+			},
+			log: func(ctx context.Context, l *slog.Logger) {
+				l.InfoContext(ctx, "msg")
+			},
+			want: map[string]any{
+				"request_id":    "request-1",
+				"trace_id":      "5759e988bd862e3fe1be46a994272793",
+				"span_id":       "53995c3f42cd8ad8",
+				"principal":     "alice",
+				"tenant":        "acme",
+				"authenticated": true,
+			},
+		},
+		{
+			name: "success:empty-context",
+			ctx:  context.Background(),
+			log: func(ctx context.Context, l *slog.Logger) {
+				l.InfoContext(ctx, "msg", "key", "value")
+			},
+			want: map[string]any{"key": "value"},
+		},
+		{
+			name: "success:existing-keys-kept",
+			ctx:  requestCtx,
+			log: func(ctx context.Context, l *slog.Logger) {
+				l.With("principal", "bob").InfoContext(ctx, "msg", "request_id", "request-2")
+			},
+			want: map[string]any{
+				"request_id": "request-2",
+				"trace_id":   "5759e988bd862e3fe1be46a994272793",
+				"span_id":    "53995c3f42cd8ad8",
+				"principal":  "bob",
+			},
+		},
+		{
+			name: "success:group",
+			ctx:  WithRequestID(context.Background(), "request-1"),
+			log: func(ctx context.Context, l *slog.Logger) {
+				l.With("request_id", "outer").WithGroup("g").InfoContext(ctx, "msg", "key", "value")
+			},
+			want: map[string]any{
+				"request_id": "outer",
+				"g":          map[string]any{"key": "value", "request_id": "request-1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			l := slog.New(NewContextHandler(slog.NewJSONHandler(b, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}), tt.opts...))
+			tt.log(tt.ctx, l)
+
+			var got map[string]any
+			require.NoError(t, json.Unmarshal(b.Bytes(), &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestContextHandler_Enabled(t *testing.T) {
+	h := NewContextHandler(slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelError))
+}
@@ -0,0 +1,18 @@
+package net
+
+import "context"
+
+// WithPrincipal saves an identifier of the authenticated principal (e.g. user or API client ID) into the context.
+// It is expected to be called by an authentication middleware.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, contextKey.principal, principal)
+}
+
+// PrincipalFromCtx extracts an identifier of the authenticated principal from the context.
+func PrincipalFromCtx(ctx context.Context) string {
+	principal, ok := ctx.Value(contextKey.principal).(string)
+	if !ok {
+		return ""
+	}
+	return principal
+}
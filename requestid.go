@@ -2,23 +2,104 @@ package net
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-type ctxKeyRequestID struct{}
+const (
+	// crockfordAlphabet is Crockford's Base32 alphabet used by ULID.
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	ulidLength        = 26
+	ulidTimeShift     = 16
+	base32Bits        = 5
+	base32Mask        = 1<<base32Bits - 1
+	uint64Bits        = 64
+
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// base62Mask selects 6 random bits, values outside the alphabet are rejected to avoid bias.
+	base62Mask = 1<<6 - 1
+)
+
+type (
+	ctxKeyRequestID       struct{}
+	ctxKeyParentRequestID struct{}
+	ctxKeyPrincipal       struct{}
+	ctxKeyClient          struct{}
+	ctxKeyTrace           struct{}
+	ctxKeyRoutePattern    struct{}
+	ctxKeyLanguage        struct{}
+	ctxKeyCSPNonce        struct{}
+)
 
 var (
 	contextKey = struct {
-		requestID ctxKeyRequestID
+		requestID       ctxKeyRequestID
+		parentRequestID ctxKeyParentRequestID
+		principal       ctxKeyPrincipal
+		client          ctxKeyClient
+		trace           ctxKeyTrace
+		routePattern    ctxKeyRoutePattern
+		language        ctxKeyLanguage
+		cspNonce        ctxKeyCSPNonce
 	}{}
 )
 
+// RequestIDGenerator returns a new unique request ID.
+type RequestIDGenerator func() string
+
 // NewRequestID returns generated UUID.
 func NewRequestID() string {
 	return uuid.New().String()
 }
 
+// NewUUIDv7RequestID returns a time-ordered UUID (version 7), which sorts by the time of generation.
+func NewUUIDv7RequestID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return NewRequestID()
+	}
+	return id.String()
+}
+
+// NewULIDRequestID returns a ULID, a 26 characters long time-ordered identifier encoded in Crockford's Base32,
+// e.g. 01ARZ3NDEKTSV4RRFFQ69G5FAV.
+func NewULIDRequestID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<ulidTimeShift) //nolint:gosec // time is positive
+	_, _ = rand.Read(b[6:])
+
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	id := make([]byte, ulidLength)
+	for i := ulidLength - 1; i >= 0; i-- {
+		id[i] = crockfordAlphabet[lo&base32Mask]
+		lo = lo>>base32Bits | hi<<(uint64Bits-base32Bits)
+		hi >>= base32Bits
+	}
+	return string(id)
+}
+
+// PrefixedRequestIDGenerator returns a generator of short request IDs consisting of the prefix
+// and the number of random alphanumeric characters, e.g. req_3kTMd92LxQ7aPq1Z.
+func PrefixedRequestIDGenerator(prefix string, length int) RequestIDGenerator {
+	return func() string {
+		id := make([]byte, 0, len(prefix)+length)
+		id = append(id, prefix...)
+		buf := make([]byte, length)
+		for len(id) < cap(id) {
+			_, _ = rand.Read(buf)
+			for _, c := range buf {
+				if c&base62Mask < byte(len(base62Alphabet)) && len(id) < cap(id) {
+					id = append(id, base62Alphabet[c&base62Mask])
+				}
+			}
+		}
+		return string(id)
+	}
+}
+
 // WithRequestID saves request ID into the context.
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, contextKey.requestID, requestID)
@@ -32,3 +113,17 @@ func RequestIDFromCtx(ctx context.Context) string {
 	}
 	return requestID
 }
+
+// WithParentRequestID saves the request ID received from the upstream service into the context.
+func WithParentRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey.parentRequestID, requestID)
+}
+
+// ParentRequestIDFromCtx extracts the request ID received from the upstream service from the context.
+func ParentRequestIDFromCtx(ctx context.Context) string {
+	requestID, ok := ctx.Value(contextKey.parentRequestID).(string)
+	if !ok {
+		return ""
+	}
+	return requestID
+}
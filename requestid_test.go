@@ -0,0 +1,76 @@
+package net
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDGenerators(t *testing.T) {
+	tests := []struct {
+		name      string
+		generator RequestIDGenerator
+		pattern   string
+	}{
+		{
+			name:      "success:uuid-v4",
+			generator: NewRequestID,
+			pattern:   `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+		},
+		{
+			name:      "success:uuid-v7",
+			generator: NewUUIDv7RequestID,
+			pattern:   `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+		},
+		{
+			name:      "success:ulid",
+			generator: NewULIDRequestID,
+			pattern:   `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`,
+		},
+		{
+			name:      "success:prefixed",
+			generator: PrefixedRequestIDGenerator("req_", 16),
+			pattern:   `^req_[0-9A-Za-z]{16}$`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := map[string]struct{}{}
+			for range 100 {
+				id := tt.generator()
+				assert.Regexp(t, regexp.MustCompile(tt.pattern), id)
+				assert.NotContains(t, seen, id)
+				seen[id] = struct{}{}
+			}
+		})
+	}
+}
+
+func TestNewULIDRequestID_TimeOrdered(t *testing.T) {
+	first := NewULIDRequestID()
+	time.Sleep(2 * time.Millisecond)
+	second := NewULIDRequestID()
+	assert.Less(t, first, second)
+
+	before := time.Now().UnixMilli()
+	id := NewULIDRequestID()
+	ms := int64(0)
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockfordAlphabet, c))
+	}
+	assert.GreaterOrEqual(t, ms, before)
+	assert.LessOrEqual(t, ms, time.Now().UnixMilli())
+}
+
+func TestNewUUIDv7RequestID_TimeOrdered(t *testing.T) {
+	first := uuid.MustParse(NewUUIDv7RequestID())
+	time.Sleep(2 * time.Millisecond)
+	second := uuid.MustParse(NewUUIDv7RequestID())
+	require.Equal(t, uuid.Version(7), first.Version())
+	assert.Less(t, first.String(), second.String())
+}
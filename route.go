@@ -0,0 +1,22 @@
+package net
+
+import "context"
+
+// RoutePatternFunc returns the route pattern matched by the router, e.g. "/users/{id}".
+// The pattern is resolved while routing, which usually happens after middlewares are called,
+// so it is evaluated only when needed.
+type RoutePatternFunc func() string
+
+// WithRoutePattern saves the function returning the route pattern of the request into the context.
+func WithRoutePattern(ctx context.Context, f RoutePatternFunc) context.Context {
+	return context.WithValue(ctx, contextKey.routePattern, f)
+}
+
+// RoutePatternFromCtx returns the route pattern matched by the router, empty if not known (yet).
+func RoutePatternFromCtx(ctx context.Context) string {
+	f, ok := ctx.Value(contextKey.routePattern).(RoutePatternFunc)
+	if !ok || f == nil {
+		return ""
+	}
+	return f()
+}
@@ -0,0 +1,65 @@
+package net
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	traceIDSize = 16
+	spanIDSize  = 8
+)
+
+// Trace identifies the request within a distributed trace, following W3C Trace Context.
+type Trace struct {
+	// TraceID is a 32 characters long lowercase hex identifier of the whole trace.
+	TraceID string
+	// SpanID is a 16 characters long lowercase hex identifier of the span handling the request.
+	SpanID string
+	// ParentSpanID is an identifier of the caller's span, empty if the trace started with the request.
+	ParentSpanID string
+	// Sampled reports whether the caller may have recorded the trace.
+	Sampled bool
+	// State is a vendor-specific trace state (tracestate header), passed as is.
+	State string
+}
+
+// NewTraceID returns a random trace ID.
+func NewTraceID() string {
+	return randomHex(traceIDSize)
+}
+
+// NewSpanID returns a random span ID.
+func NewSpanID() string {
+	return randomHex(spanIDSize)
+}
+
+// WithTrace saves the trace into the context.
+func WithTrace(ctx context.Context, trace Trace) context.Context {
+	return context.WithValue(ctx, contextKey.trace, trace)
+}
+
+// TraceFromCtx extracts the trace from the context.
+func TraceFromCtx(ctx context.Context) (Trace, bool) {
+	trace, ok := ctx.Value(contextKey.trace).(Trace)
+	return trace, ok
+}
+
+// TraceIDFromCtx extracts the trace ID from the context.
+func TraceIDFromCtx(ctx context.Context) string {
+	trace, _ := TraceFromCtx(ctx)
+	return trace.TraceID
+}
+
+// SpanIDFromCtx extracts the span ID from the context.
+func SpanIDFromCtx(ctx context.Context) string {
+	trace, _ := TraceFromCtx(ctx)
+	return trace.SpanID
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}